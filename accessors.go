@@ -0,0 +1,44 @@
+package schemable
+
+// Accessors is implemented by pointers to structs with generated accessor
+// methods, which Schemers use instead of reflection to scan and read the
+// struct's fields. The cmd/schemable-accessors command generates them:
+//
+//	//go:generate go run github.com/refractionist/schemable/cmd/schemable-accessors -type ComicTitle
+//
+// Bind falls back to reflection if the generated columns do not match the
+// struct's db tags, like when the generated code is out of date.
+type Accessors interface {
+	// SchemableColumns returns the columns of the db tagged fields, in order.
+	SchemableColumns() []string
+	// SchemableRefs returns pointers to the db tagged fields.
+	SchemableRefs() []any
+	// SchemableValues returns the values of the db tagged fields, with
+	// non-nil pointer fields dereferenced.
+	SchemableValues() []any
+}
+
+// hasAccessors returns true if the target has Accessors for the given fields.
+func hasAccessors(tgt any, fields []*field) bool {
+	a, ok := tgt.(Accessors)
+	if !ok {
+		return false
+	}
+
+	cols := a.SchemableColumns()
+	if len(cols) != len(fields) {
+		return false
+	}
+	for i, f := range fields {
+		if cols[i] != f.column {
+			return false
+		}
+	}
+	return true
+}
+
+// UsesAccessors returns true if the Schemer uses the generated Accessors of
+// type T instead of reflection.
+func (s *Schemer[T]) UsesAccessors() bool {
+	return s.accessors
+}
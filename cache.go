@@ -0,0 +1,344 @@
+package schemable
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/refractionist/schemable/internal/sqlstub"
+)
+
+// Cache stores encoded Targets for the primary key loads of Schemers set up
+// with SetCache. Any shared cache, like Redis or memcached, can implement it.
+type Cache interface {
+	// Get returns the value of the key, or false if it is missing or expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores the value of the key for ttl, or without expiring if ttl is 0.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the key, if it exists.
+	Delete(ctx context.Context, key string) error
+}
+
+// SetCache serves the primary key loads of Recorder.Load and Find from the
+// Cache, storing rows loaded from the database for ttl. Targets are encoded as
+// the driver values of their columns, and scanned back like rows from the
+// database, so Scanner and Valuer fields round trip. Recorder.Update and
+// Recorder.Delete delete their row's entry, and DeleteWhere invalidates the
+// whole table. A load that races with them does not store the row it read.
+// Loads in a transaction skip the cache, and writes in one invalidate entries
+// again after it commits. Cache errors never fail a query: loads fall back to
+// the database, and failed invalidations are logged as the QueryEvent's
+// CacheErr. A nil Cache disables caching. Set it before the Schemer is used.
+func (s *Schemer[T]) SetCache(c Cache, ttl time.Duration) {
+	s.cache, s.cacheTTL = c, ttl
+}
+
+// readCache returns the Schemer's Cache for loads, or nil if there is none,
+// the Schemer has no primary keys, or the context is in a transaction.
+func (s *Schemer[T]) readCache(ctx context.Context) Cache {
+	if s.cache == nil || len(s.keys) == 0 {
+		return nil
+	}
+	if _, ok := ClientFrom(ctx).(*TxnClient); ok {
+		return nil
+	}
+	return s.cache
+}
+
+// loadCache sets the Recorder Target's columns from its cached row, returning
+// false if there is none. Cache errors count as misses. Values are scanned
+// like the columns of a row from the database.
+func (r *Recorder[T]) loadCache(ctx context.Context) bool {
+	c := r.Schemer.readCache(ctx)
+	if c == nil {
+		return false
+	}
+
+	key, err := r.cacheKey(ctx)
+	if err != nil {
+		return false
+	}
+	b, ok, err := c.Get(ctx, key)
+	if err != nil || !ok {
+		return false
+	}
+
+	var cols map[string]any
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&cols); err != nil {
+		return false
+	}
+	names := r.Schemer.Columns(false)
+	vals := make([]any, len(names))
+	for i, f := range r.nonKeyFields() {
+		v, ok := cols[f.column]
+		if !ok {
+			return false
+		}
+		vals[i] = v
+	}
+
+	tmp := r.Schemer.Record(nil)
+	if err := sqlstub.Row(names, [][]any{vals}).Scan(tmp.fieldRefs(false)...); err != nil {
+		return false
+	}
+	src := reflect.ValueOf(tmp.Target).Elem()
+	dst := reflect.ValueOf(r.Target).Elem()
+	for _, f := range r.nonKeyFields() {
+		dst.FieldByName(f.name).Set(src.FieldByName(f.name))
+	}
+	return true
+}
+
+// cacheFill is a load of a row from the database that storeCache may cache.
+// Its token is stored under the fill key of the row until then, and deleted
+// with the row's entry if the row is invalidated in the meantime.
+type cacheFill struct {
+	key   string
+	token []byte
+}
+
+// startFill marks the Recorder's row as being loaded from the database. It
+// returns a zero cacheFill if the row can't be cached.
+func (r *Recorder[T]) startFill(ctx context.Context) cacheFill {
+	c := r.Schemer.readCache(ctx)
+	if c == nil {
+		return cacheFill{}
+	}
+	key, err := r.cacheKey(ctx)
+	if err != nil {
+		return cacheFill{}
+	}
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return cacheFill{}
+	}
+	if err := c.Set(ctx, fillKey(key), token, r.Schemer.cacheTTL); err != nil {
+		return cacheFill{}
+	}
+	return cacheFill{key: key, token: token}
+}
+
+// storeCache caches the driver values of the Recorder Target's columns, if
+// the row was not invalidated since the fill started. It ignores cache errors
+// and values that drivers cannot store. Rows of a table invalidated since then
+// are stored under the key of the old generation, which is never read again.
+func (r *Recorder[T]) storeCache(ctx context.Context, fill cacheFill) {
+	c := r.Schemer.readCache(ctx)
+	if c == nil || fill.key == "" {
+		return
+	}
+
+	cols := r.Values()
+	for col, v := range cols {
+		dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return
+		}
+		cols[col] = dv
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cols); err != nil {
+		return
+	}
+
+	token, ok, err := c.Get(ctx, fillKey(fill.key))
+	if err != nil || !ok || !bytes.Equal(token, fill.token) {
+		return
+	}
+	c.Set(ctx, fill.key, buf.Bytes(), r.Schemer.cacheTTL)
+	c.Delete(ctx, fillKey(fill.key))
+}
+
+func fillKey(key string) string {
+	return key + ":fill"
+}
+
+// nonKeyFields returns the Schemer's fields except the primary keys, in the
+// order of Columns(false).
+func (r *Recorder[T]) nonKeyFields() []*field {
+	fields := make([]*field, 0, len(r.Schemer.fields))
+	for _, f := range r.Schemer.fields {
+		if !f.isKey {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+func init() {
+	// the only driver value that gob does not know
+	gob.Register(time.Time{})
+}
+
+// invalidateCache deletes the cached entry of the Recorder's row. In a
+// transaction, it is deleted again once the transaction commits, in case it
+// was cached from another context before then.
+func (r *Recorder[T]) invalidateCache(ctx context.Context) error {
+	c := r.Schemer.cache
+	if c == nil || len(r.Schemer.keys) == 0 {
+		return nil
+	}
+
+	del := func() error {
+		key, err := r.cacheKey(ctx)
+		if err == nil {
+			err = c.Delete(ctx, key)
+		}
+		if err == nil {
+			err = c.Delete(ctx, fillKey(key))
+		}
+		return err
+	}
+	if tx, ok := ClientFrom(ctx).(*TxnClient); ok {
+		tx.OnCommit(func() { del() })
+	}
+	if err := del(); err != nil {
+		return fmt.Errorf("schemable: cache: %w", err)
+	}
+	return nil
+}
+
+// invalidateTable starts a new generation of cache keys for the Schemer's
+// table, so that none of its cached entries are read again.
+func (s *Schemer[T]) invalidateTable(ctx context.Context) error {
+	if s.cache == nil || len(s.keys) == 0 {
+		return nil
+	}
+
+	if tx, ok := ClientFrom(ctx).(*TxnClient); ok {
+		tx.OnCommit(func() { s.newCacheGen(ctx) })
+	}
+	if _, err := s.newCacheGen(ctx); err != nil {
+		return fmt.Errorf("schemable: cache: %w", err)
+	}
+	return nil
+}
+
+// cacheKey returns the key of the Recorder's row in the current generation of
+// its table.
+func (r *Recorder[T]) cacheKey(ctx context.Context) (string, error) {
+	s := r.Schemer
+	gen, ok, err := s.cache.Get(ctx, s.cacheGenKey())
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		// a new generation, in case the old one was evicted
+		if gen, err = s.newCacheGen(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	ids := r.keyStrings()
+	for i, id := range ids {
+		ids[i] = url.PathEscape(id)
+	}
+	return "schemable:" + s.table + ":" + string(gen) + ":" + strings.Join(ids, ":"), nil
+}
+
+func (s *Schemer[T]) newCacheGen(ctx context.Context) ([]byte, error) {
+	gen := []byte(strconv.FormatInt(time.Now().UnixNano(), 36))
+	return gen, s.cache.Set(ctx, s.cacheGenKey(), gen, 0)
+}
+
+func (s *Schemer[T]) cacheGenKey() string {
+	return "schemable:" + s.table + ":gen"
+}
+
+// LRUCache is an in-process Cache that holds a limited number of entries,
+// evicting the least recently used ones when it is full.
+type LRUCache struct {
+	limit int
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+}
+
+// lruEntry is a cached value. A zero expires never expires.
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// NewLRUCache returns an LRUCache that holds up to limit entries.
+func NewLRUCache(limit int) *LRUCache {
+	return &LRUCache{
+		limit: limit,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value of the key, or false if it is missing or expired.
+func (lc *LRUCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	el, ok := lc.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := el.Value.(*lruEntry)
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		lc.lru.Remove(el)
+		delete(lc.items, key)
+		return nil, false, nil
+	}
+	lc.lru.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set stores the value of the key for ttl, or without expiring if ttl is 0.
+func (lc *LRUCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if el, ok := lc.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		lc.lru.MoveToFront(el)
+		return nil
+	}
+
+	lc.items[key] = lc.lru.PushFront(&lruEntry{key: key, value: value, expires: expires})
+	for lc.lru.Len() > lc.limit {
+		el := lc.lru.Back()
+		lc.lru.Remove(el)
+		delete(lc.items, el.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// Delete removes the key, if it exists.
+func (lc *LRUCache) Delete(ctx context.Context, key string) error {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	if el, ok := lc.items[key]; ok {
+		lc.lru.Remove(el)
+		delete(lc.items, key)
+	}
+	return nil
+}
+
+// Len returns the number of entries, including expired ones that have not
+// been evicted yet.
+func (lc *LRUCache) Len() int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	return lc.lru.Len()
+}
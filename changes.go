@@ -0,0 +1,117 @@
+package schemable
+
+import (
+	"context"
+	"sync"
+)
+
+// ChangeKind is the kind of write that a Change records.
+type ChangeKind int
+
+const (
+	Inserted ChangeKind = iota + 1
+	Updated
+	Deleted
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Inserted:
+		return "inserted"
+	case Updated:
+		return "updated"
+	case Deleted:
+		return "deleted"
+	}
+	return "unknown change"
+}
+
+// Change is a successful write to a Schemer's table, sent to its subscribers.
+type Change[T any] struct {
+	Kind  ChangeKind
+	Table string
+	// Target is a copy of the Recorder Target as it was written. It is nil for
+	// the rows deleted by DeleteWhere, which are not known.
+	Target *T
+	// Values are the Target's columns for Inserted, including its primary keys,
+	// and the UpdatedValues written for Updated.
+	Values map[string]any
+}
+
+// Subscribe calls fn with a Change after every successful Insert, Update, or
+// Delete of the Schemer's Recorders, and DeleteWhere. Writes in a transaction
+// are sent once it commits, and dropped if it rolls back. fn runs in the
+// writing goroutine, with the writer's context. For writes in a transaction,
+// the context has the client that began it instead of the committed
+// *TxnClient. Writes to a DryRunClient, or in its transactions, are not sent.
+// The returned func removes the subscription.
+func (s *Schemer[T]) Subscribe(fn func(ctx context.Context, c Change[T])) (unsubscribe func()) {
+	return s.changes.add(fn)
+}
+
+// publish sends a Change to the Schemer's subscribers, after the context's
+// transaction commits if there is one. Writes to a DryRunClient are not sent.
+func (s *Schemer[T]) publish(ctx context.Context, kind ChangeKind, tgt *T, values map[string]any) {
+	fns := s.changes.list()
+	if len(fns) == 0 || isDryRun(ClientFrom(ctx)) {
+		return
+	}
+
+	c := Change[T]{Kind: kind, Table: s.table, Values: values}
+	if tgt != nil {
+		written := *tgt
+		c.Target = &written
+	}
+	send := func(ctx context.Context) {
+		for _, fn := range fns {
+			fn(ctx, c)
+		}
+	}
+
+	if tx, ok := ClientFrom(ctx).(*TxnClient); ok {
+		tx.OnCommit(func() { send(WithClient(ctx, tx.parent)) })
+		return
+	}
+	send(ctx)
+}
+
+// changeFeed holds a Schemer's subscribers, in the order they subscribed.
+type changeFeed[T any] struct {
+	mu   sync.Mutex
+	last int
+	subs []changeSub[T]
+}
+
+type changeSub[T any] struct {
+	id int
+	fn func(context.Context, Change[T])
+}
+
+func (f *changeFeed[T]) add(fn func(context.Context, Change[T])) func() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.last++
+	id := f.last
+	f.subs = append(f.subs, changeSub[T]{id: id, fn: fn})
+
+	return func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for i, sub := range f.subs {
+			if sub.id == id {
+				f.subs = append(f.subs[:i:i], f.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (f *changeFeed[T]) list() []func(context.Context, Change[T]) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fns := make([]func(context.Context, Change[T]), len(f.subs))
+	for i, sub := range f.subs {
+		fns[i] = sub.fn
+	}
+	return fns
+}
@@ -22,7 +22,42 @@ type Client interface {
 type DBClient struct {
 	db      *sql.DB
 	builder *sq.StatementBuilderType
-	logger  QueryLogger
+	handler Handler
+	retry   RetryPolicy
+	settings
+}
+
+// settings are the options that a DBClient shares with the transactions it
+// begins.
+type settings struct {
+	dialect    Dialect
+	logger     QueryEventLogger
+	metrics    *Metrics
+	tracer     Tracer
+	comments   queryComments
+	stmts      *stmtCache
+	timeouts   Timeouts
+	middleware []Middleware
+}
+
+// clone copies the settings for a new transaction, so that changes to the
+// DBClient do not affect it.
+func (s settings) clone() settings {
+	s.middleware = append([]Middleware(nil), s.middleware...)
+	return s
+}
+
+// commenter returns the sqlcommenter settings.
+func (s settings) commenter() queryComments {
+	return s.comments
+}
+
+// logEvent sends the event to the logger and metrics collector.
+func (s settings) logEvent(ctx context.Context, e *QueryEvent) {
+	s.logger.LogQueryEvent(ctx, e)
+	if s.metrics != nil {
+		s.metrics.LogQueryEvent(ctx, e)
+	}
 }
 
 // New initiates a new database connection with the given connection string
@@ -32,9 +67,18 @@ func New(driver, conn string) (*DBClient, error) {
 	if err != nil {
 		return nil, err
 	}
+	return FromDB(db), nil
+}
 
+// FromDB returns a DBClient for an already opened *sql.DB instance.
+func FromDB(db *sql.DB) *DBClient {
 	builder := sq.StatementBuilder.RunWith(db)
-	return &DBClient{db: db, logger: nilLogger, builder: &builder}, nil
+	return &DBClient{
+		db:       db,
+		builder:  &builder,
+		handler:  connHandler(db),
+		settings: settings{dialect: detectDialect(db), logger: nilLogger},
+	}
 }
 
 // DB returns the open *sql.DB instance for this Client.
@@ -42,8 +86,32 @@ func (c *DBClient) DB() *sql.DB {
 	return c.db
 }
 
+// Dialect returns the SQL dialect of the database, detected from its driver.
+func (c *DBClient) Dialect() Dialect {
+	return c.dialect
+}
+
+// SetDialect overrides the detected SQL dialect of the database.
+func (c *DBClient) SetDialect(d Dialect) {
+	c.dialect = d
+}
+
 // SetLogger sets the given logger, or resetting it to a no-op logger if nil.
+// The logger receives QueryEvents if it implements QueryEventLogger.
 func (c *DBClient) SetLogger(l QueryLogger) {
+	switch el := l.(type) {
+	case nil:
+		c.logger = nilLogger
+	case QueryEventLogger:
+		c.logger = el
+	default:
+		c.logger = eventLogger{l}
+	}
+}
+
+// SetEventLogger sets the given QueryEvent logger, or resetting it to a no-op
+// logger if nil.
+func (c *DBClient) SetEventLogger(l QueryEventLogger) {
 	if l == nil {
 		c.logger = nilLogger
 	} else {
@@ -51,9 +119,103 @@ func (c *DBClient) SetLogger(l QueryLogger) {
 	}
 }
 
+// Use adds middleware around Exec, Query, and QueryRow. The first middleware
+// added is the outermost. Transactions started with Begin inherit the
+// middleware added before them.
+func (c *DBClient) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+	c.handler = chain(c.middleware, c.base())
+}
+
+// SetTimeouts sets default read, write, and transaction timeouts for queries
+// whose context has no earlier deadline. Transactions started with Begin
+// inherit them.
+func (c *DBClient) SetTimeouts(t Timeouts) {
+	c.timeouts = t
+}
+
+// SetRetryPolicy sets the policy for retrying queries that fail with a
+// transient error. Queries in transactions are not retried.
+func (c *DBClient) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
+// SetStmtCache caches up to size prepared statements for the SQL built by
+// Schemers and Recorders, reusing them when the same SQL runs again, including
+// in transactions started with Begin. Other SQL from Exec and Query is not
+// prepared. Cached statements are prepared without sqlcommenter comments. The
+// least recently used statements are closed when the cache is full. A size of
+// 0 disables the cache.
+func (c *DBClient) SetStmtCache(size int) {
+	if c.stmts != nil {
+		c.stmts.clear()
+		c.stmts = nil
+	}
+	if size > 0 {
+		c.stmts = newStmtCache(c.db, size)
+	}
+	c.handler = chain(c.middleware, c.base())
+}
+
+// StmtCacheStats returns the prepared statement cache counters.
+func (c *DBClient) StmtCacheStats() StmtCacheStats {
+	if c.stmts == nil {
+		return StmtCacheStats{}
+	}
+	return c.stmts.snapshot()
+}
+
+// ClearStmtCache closes every cached prepared statement.
+func (c *DBClient) ClearStmtCache() {
+	if c.stmts != nil {
+		c.stmts.clear()
+	}
+}
+
+// base returns the Handler at the end of the middleware chain.
+func (c *DBClient) base() Handler {
+	if c.stmts != nil {
+		return c.stmts.handler(nil)
+	}
+	return connHandler(c.db)
+}
+
+// SetMetrics sets the given Metrics collector to record every logged query,
+// or removes it if nil.
+func (c *DBClient) SetMetrics(m *Metrics) {
+	c.metrics = m
+}
+
+// SetQueryComments sets whether sqlcommenter comments are appended to every
+// statement built by a Schemer or Recorder. Comments include the given static
+// tags, any tags from WithQueryTags, and the table and operation.
+func (c *DBClient) SetQueryComments(enabled bool, tags map[string]string) {
+	static := make(map[string]string, len(tags))
+	for k, v := range tags {
+		static[k] = v
+	}
+	c.comments = queryComments{enabled: enabled, tags: static}
+}
+
+// SetTracer sets the given Tracer to start spans for Schemer and Recorder
+// operations, or removes it if nil.
+func (c *DBClient) SetTracer(t Tracer) {
+	c.tracer = t
+}
+
+// Tracer returns the Tracer for this client, or nil if it has none.
+func (c *DBClient) Tracer() Tracer {
+	return c.tracer
+}
+
 // LogQuery logs the given query info to this client's logger.
 func (c *DBClient) LogQuery(ctx context.Context, q string, args []any) {
-	c.logger.LogQuery(ctx, q, args)
+	c.logEvent(ctx, &QueryEvent{SQL: q, Args: args, Duration: DBDurationFrom(ctx)})
+}
+
+// LogQueryEvent logs the given QueryEvent to this client's logger.
+func (c *DBClient) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+	c.logEvent(ctx, e)
 }
 
 // Builder is the squirrel query builder for this db connection.
@@ -64,13 +226,15 @@ func (c *DBClient) Builder() *sq.StatementBuilderType {
 // Exec executes a query without returning any rows. The args are for any
 // placeholder parameters in the query.
 func (c *DBClient) Exec(ctx context.Context, q string, args ...any) (sql.Result, error) {
-	return c.db.ExecContext(ctx, q, args...)
+	res := c.runWithRetry(ctx, Statement{Kind: ExecStatement, SQL: q, Args: args})
+	return res.Result, res.Err
 }
 
 // Query executes a query that returns rows, typically a SELECT. The args are
 // for any placeholder parameters in the query.
 func (c *DBClient) Query(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
-	return c.db.QueryContext(ctx, q, args...)
+	res := c.runWithRetry(ctx, Statement{Kind: QueryStatement, SQL: q, Args: args})
+	return res.Rows, res.Err
 }
 
 // QueryRow executes a query that is expected to return at most one row.
@@ -79,12 +243,13 @@ func (c *DBClient) Query(ctx context.Context, q string, args ...any) (*sql.Rows,
 // will return ErrNoRows. Otherwise, the *Row's Scan scans the first selected
 // row and discards the rest.
 func (c *DBClient) QueryRow(ctx context.Context, q string, args ...any) *sql.Row {
-	return c.db.QueryRowContext(ctx, q, args...)
+	return rowFrom(c.runWithRetry(ctx, Statement{Kind: QueryRowStatement, SQL: q, Args: args}))
 }
 
 // Close closes the database and prevents new queries from starting. Close then
 // waits for all queries that have started processing on the server to finish.
 func (c *DBClient) Close() error {
+	c.ClearStmtCache()
 	return c.db.Close()
 }
 
@@ -98,39 +263,69 @@ func (c *DBClient) Ping(ctx context.Context) error {
 type TxnClient struct {
 	tx      *sql.Tx
 	builder *sq.StatementBuilderType
-	logger  QueryLogger
+	handler Handler
+	timeout *deadline
+	// readOnly rejects writes with ErrReadOnly.
+	readOnly bool
+	hooks    txnHooks
+	// parent is the client that began the transaction.
+	parent Client
+	settings
 }
 
-// Begin starts a transaction. See database/sql#DB.BeginTx.
+// Begin starts a transaction. See database/sql#DB.BeginTx. Writes return
+// ErrReadOnly if the ReadOnly option is set.
 func (c *DBClient) Begin(ctx context.Context, opts *sql.TxOptions) (*TxnClient, error) {
+	var timeout *deadline
+	if tctx, cancel, ok := withTimeout(ctx, c.timeouts.Txn); ok {
+		timeout = &deadline{kind: "transaction", parent: ctx, ctx: tctx, cancel: cancel, timeout: c.timeouts.Txn}
+		ctx = tctx
+	}
+
 	tx, err := c.db.BeginTx(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, timeout.end(err)
 	}
 	builder := sq.StatementBuilder.RunWith(tx)
-	return &TxnClient{tx: tx, logger: c.logger, builder: &builder}, nil
+	tc := &TxnClient{
+		tx:       tx,
+		builder:  &builder,
+		timeout:  timeout,
+		readOnly: opts != nil && opts.ReadOnly,
+		parent:   c,
+		settings: c.settings.clone(),
+	}
+	tc.handler = chain(tc.middleware, tc.base())
+	return tc, nil
 }
 
-// Commit commits the transaction.
+// Commit commits the transaction, then runs the funcs added with OnCommit, or
+// the ones added with OnRollback if it fails.
 func (c *TxnClient) Commit() error {
-	return c.tx.Commit()
+	err := c.timeout.end(c.tx.Commit())
+	c.hooks.run(err == nil)
+	return err
 }
 
-// Rollback aborts the transaction.
+// Rollback aborts the transaction, then runs the funcs added with OnRollback.
 func (c *TxnClient) Rollback() error {
-	return c.tx.Rollback()
+	err := c.timeout.end(c.tx.Rollback())
+	c.hooks.run(false)
+	return err
 }
 
 // Exec executes a query without returning any rows. The args are for any
 // placeholder parameters in the query.
 func (c *TxnClient) Exec(ctx context.Context, q string, args ...any) (sql.Result, error) {
-	return c.tx.ExecContext(ctx, q, args...)
+	res := c.run(ctx, c.handler, Statement{Kind: ExecStatement, SQL: q, Args: args})
+	return res.Result, res.Err
 }
 
 // Query executes a query that returns rows, typically a SELECT. The args are
 // for any placeholder parameters in the query.
 func (c *TxnClient) Query(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
-	return c.tx.QueryContext(ctx, q, args...)
+	res := c.run(ctx, c.handler, Statement{Kind: QueryStatement, SQL: q, Args: args})
+	return res.Rows, res.Err
 }
 
 // QueryRow executes a query that is expected to return at most one row.
@@ -139,12 +334,46 @@ func (c *TxnClient) Query(ctx context.Context, q string, args ...any) (*sql.Rows
 // will return ErrNoRows. Otherwise, the *Row's Scan scans the first selected
 // row and discards the rest.
 func (c *TxnClient) QueryRow(ctx context.Context, q string, args ...any) *sql.Row {
-	return c.tx.QueryRowContext(ctx, q, args...)
+	return rowFrom(c.run(ctx, c.handler, Statement{Kind: QueryRowStatement, SQL: q, Args: args}))
+}
+
+// Use adds middleware around Exec, Query, and QueryRow for this transaction,
+// inside of any middleware inherited from its DBClient.
+func (c *TxnClient) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+	c.handler = chain(c.middleware, c.base())
+}
+
+// base returns the Handler at the end of the middleware chain.
+func (c *TxnClient) base() Handler {
+	h := connHandler(c.tx)
+	if c.stmts != nil {
+		h = c.stmts.handler(c.tx)
+	}
+	if c.readOnly {
+		return readOnlyHandler(h)
+	}
+	return h
+}
+
+// Dialect returns the SQL dialect of the database.
+func (c *TxnClient) Dialect() Dialect {
+	return c.dialect
+}
+
+// Tracer returns the Tracer for this client, or nil if it has none.
+func (c *TxnClient) Tracer() Tracer {
+	return c.tracer
 }
 
 // LogQuery logs the given query info to this client's logger.
 func (c *TxnClient) LogQuery(ctx context.Context, q string, args []any) {
-	c.logger.LogQuery(ctx, q, args)
+	c.logEvent(ctx, &QueryEvent{SQL: q, Args: args, Duration: DBDurationFrom(ctx), InTx: true})
+}
+
+// LogQueryEvent logs the given QueryEvent to this client's logger.
+func (c *TxnClient) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+	c.logEvent(ctx, e)
 }
 
 // Builder is the squirrel query builder for this db connection.
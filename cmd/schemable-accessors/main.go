@@ -0,0 +1,38 @@
+// Command schemable-accessors generates schemable.Accessors methods for db
+// tagged structs, so Schemers scan and read them without reflection. It is
+// meant to run with go generate, in the package of the structs:
+//
+//	//go:generate go run github.com/refractionist/schemable/cmd/schemable-accessors -type ComicTitle,Publisher
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/refractionist/schemable/gen"
+)
+
+func main() {
+	types := flag.String("type", "", "comma separated struct names (default all structs with db tags)")
+	out := flag.String("o", "schemable_accessors.go", "output file")
+	dir := flag.String("dir", ".", "package directory")
+	flag.Parse()
+
+	var names []string
+	if *types != "" {
+		names = strings.Split(*types, ",")
+	}
+
+	var buf bytes.Buffer
+	if err := gen.Accessors(&buf, *dir, names...); err != nil {
+		fmt.Fprintln(os.Stderr, "schemable-accessors:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "schemable-accessors:", err)
+		os.Exit(1)
+	}
+}
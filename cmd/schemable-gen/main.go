@@ -0,0 +1,130 @@
+// Command schemable-gen writes Go source with tagged structs and Schemers for
+// the tables of an existing database.
+//
+//	schemable-gen -db legacy.db -pkg models -singular -o models/tables.go \
+//		-rename comic_titles=Comic -rename comic_titles.id_two=SecondID \
+//		-type DATETIME=time.Time -type comics.uuid=github.com/google/uuid.UUID
+//
+// It opens SQLite files by default. Other databases work with a -driver that
+// is registered in a build of this command.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/gen"
+)
+
+// pairs is a repeatable flag of comma separated key=value pairs.
+type pairs map[string]string
+
+func (p pairs) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p pairs) Set(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || k == "" || v == "" {
+			return fmt.Errorf("expected key=value, got %q", pair)
+		}
+		p[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return nil
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "schemable-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("schemable-gen", flag.ContinueOnError)
+	driver := fs.String("driver", "sqlite3", "database/sql driver name")
+	dsn := fs.String("db", "", "database file or connection string (required)")
+	pkg := fs.String("pkg", "models", "package name of the generated source")
+	out := fs.String("o", "", "output file (default stdout)")
+	tables := fs.String("tables", "", "comma separated tables to generate (default all)")
+	singular := fs.Bool("singular", false, "use singular struct names, like ComicTitle for comic_titles")
+	renames := pairs{}
+	fs.Var(renames, "rename", "struct names by table, or field names by table.column or column: `name=Go`")
+	vars := pairs{}
+	fs.Var(vars, "var", "Schemer variable names by table: `table=Name`")
+	types := pairs{}
+	fs.Var(types, "type", "Go types by table.column, column, or database type: `key=type`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dsn == "" {
+		fs.Usage()
+		return fmt.Errorf("-db is required")
+	}
+
+	client, err := schemable.New(*driver, *dsn)
+	if err != nil {
+		return err
+	}
+	defer client.DB().Close()
+	ctx := schemable.WithClient(context.Background(), client)
+
+	var names []string
+	if *tables != "" {
+		names = strings.Split(*tables, ",")
+	} else if names, err = schemable.Tables(ctx); err != nil {
+		return err
+	}
+
+	opts := gen.Options{
+		Package:    *pkg,
+		Singular:   *singular,
+		TypeNames:  map[string]string{},
+		VarNames:   vars,
+		FieldNames: map[string]string{},
+		Types:      types,
+	}
+	schemas := make([]*schemable.TableSchema, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		ts, err := schemable.Introspect(ctx, name)
+		if err != nil {
+			return err
+		}
+		schemas = append(schemas, ts)
+	}
+	for k, v := range renames {
+		if isTable(schemas, k) {
+			opts.TypeNames[k] = v
+		} else {
+			opts.FieldNames[k] = v
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := gen.Structs(&buf, schemas, opts); err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*out, buf.Bytes(), 0o644)
+}
+
+// isTable returns true if the name is one of the tables, and not a column.
+func isTable(schemas []*schemable.TableSchema, name string) bool {
+	for _, ts := range schemas {
+		if ts.Table == name {
+			return true
+		}
+	}
+	return false
+}
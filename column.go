@@ -0,0 +1,136 @@
+package schemable
+
+import (
+	"fmt"
+	"reflect"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Column is a column of the table that a Schemer binds to type T, with values
+// of type V. Its predicates use the table qualified column, and work in a
+// WhereFunc, DeleteFunc, LoadWhere, or Exists:
+//
+//	var titleName = schemable.ColumnOf[string](ComicTitles, "name")
+//
+//	recs, err := ComicTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+//		return q.Where(titleName.Like("X-%")).OrderBy(titleName.Asc())
+//	})
+type Column[T any, V any] struct {
+	column, selectcolumn string
+}
+
+// ColumnOf returns the column with the given name from the Schemer. It panics
+// if type T has no field for the column, or if V is not the field's type. V
+// may also be the type that a pointer or sql.Null* field holds.
+func ColumnOf[V any, T any](s *Schemer[T], name string) Column[T, V] {
+	f := s.field(name)
+	vt := reflect.TypeOf((*V)(nil)).Elem()
+	switch {
+	case f.kind == vt:
+	case f.kind.Kind() == reflect.Ptr && f.kind.Elem() == vt:
+	case nullTypes[f.kind] == vt:
+	default:
+		panic(fmt.Sprintf("schemable: %s.%s is %s, not %s", s.table, name, f.kind, vt))
+	}
+	return Column[T, V]{column: f.column, selectcolumn: f.selectcolumn}
+}
+
+// Col returns the column with the given name, with untyped values. It panics
+// if type T has no field for the column.
+func (s *Schemer[T]) Col(name string) Column[T, any] {
+	f := s.field(name)
+	return Column[T, any]{column: f.column, selectcolumn: f.selectcolumn}
+}
+
+// field returns the field of the given column, panicking if there is none.
+func (s *Schemer[T]) field(column string) *field {
+	for _, f := range s.fields {
+		if f.column == column {
+			return f
+		}
+	}
+	panic(fmt.Sprintf("schemable: %s has no column %q", s.table, column))
+}
+
+// Name returns the column name.
+func (c Column[T, V]) Name() string {
+	return c.column
+}
+
+// String returns the table qualified column name.
+func (c Column[T, V]) String() string {
+	return c.selectcolumn
+}
+
+// Eq returns a "column = value" predicate.
+func (c Column[T, V]) Eq(v V) sq.Sqlizer {
+	return sq.Eq{c.selectcolumn: v}
+}
+
+// NotEq returns a "column <> value" predicate.
+func (c Column[T, V]) NotEq(v V) sq.Sqlizer {
+	return sq.NotEq{c.selectcolumn: v}
+}
+
+// In returns a "column IN (values)" predicate. It is false if there are no
+// values.
+func (c Column[T, V]) In(vs ...V) sq.Sqlizer {
+	return sq.Eq{c.selectcolumn: vs}
+}
+
+// NotIn returns a "column NOT IN (values)" predicate. It is true if there are
+// no values.
+func (c Column[T, V]) NotIn(vs ...V) sq.Sqlizer {
+	return sq.NotEq{c.selectcolumn: vs}
+}
+
+// Gt returns a "column > value" predicate.
+func (c Column[T, V]) Gt(v V) sq.Sqlizer {
+	return sq.Gt{c.selectcolumn: v}
+}
+
+// GtOrEq returns a "column >= value" predicate.
+func (c Column[T, V]) GtOrEq(v V) sq.Sqlizer {
+	return sq.GtOrEq{c.selectcolumn: v}
+}
+
+// Lt returns a "column < value" predicate.
+func (c Column[T, V]) Lt(v V) sq.Sqlizer {
+	return sq.Lt{c.selectcolumn: v}
+}
+
+// LtOrEq returns a "column <= value" predicate.
+func (c Column[T, V]) LtOrEq(v V) sq.Sqlizer {
+	return sq.LtOrEq{c.selectcolumn: v}
+}
+
+// Like returns a "column LIKE pattern" predicate.
+func (c Column[T, V]) Like(pattern string) sq.Sqlizer {
+	return sq.Like{c.selectcolumn: pattern}
+}
+
+// NotLike returns a "column NOT LIKE pattern" predicate.
+func (c Column[T, V]) NotLike(pattern string) sq.Sqlizer {
+	return sq.NotLike{c.selectcolumn: pattern}
+}
+
+// IsNull returns a "column IS NULL" predicate.
+func (c Column[T, V]) IsNull() sq.Sqlizer {
+	return sq.Eq{c.selectcolumn: nil}
+}
+
+// IsNotNull returns a "column IS NOT NULL" predicate.
+func (c Column[T, V]) IsNotNull() sq.Sqlizer {
+	return sq.NotEq{c.selectcolumn: nil}
+}
+
+// Asc returns an ascending ORDER BY expression for the column.
+func (c Column[T, V]) Asc() string {
+	return c.selectcolumn + " ASC"
+}
+
+// Desc returns a descending ORDER BY expression for the column.
+func (c Column[T, V]) Desc() string {
+	return c.selectcolumn + " DESC"
+}
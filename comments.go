@@ -0,0 +1,84 @@
+package schemable
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// WithQueryTags returns a context with the given tags merged into any tags
+// that the context already has. Clients with query comments enabled append
+// the tags to every statement built by a Schemer or Recorder, so that each
+// query can be attributed in tools like pg_stat_statements.
+func WithQueryTags(ctx context.Context, tags map[string]string) context.Context {
+	merged := make(map[string]string, len(tags))
+	for k, v := range QueryTagsFrom(ctx) {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, tagsKey, merged)
+}
+
+// QueryTagsFrom returns the tags from WithQueryTags in the given context.
+func QueryTagsFrom(ctx context.Context) map[string]string {
+	tags, _ := ctx.Value(tagsKey).(map[string]string)
+	return tags
+}
+
+// queryComments are the sqlcommenter settings of a client.
+type queryComments struct {
+	enabled bool
+	tags    map[string]string
+}
+
+// comment appends a sqlcommenter comment to the given SQL statement with the
+// client's static tags, the context's tags, and the table and operation of the
+// statement, in that order of precedence.
+func (qc queryComments) comment(ctx context.Context, q, table, method string) string {
+	if !qc.enabled || strings.Contains(q, "/*") {
+		return q
+	}
+
+	tags := make(map[string]string, len(qc.tags)+2)
+	for k, v := range qc.tags {
+		tags[k] = v
+	}
+	for k, v := range QueryTagsFrom(ctx) {
+		tags[k] = v
+	}
+	tags["table"] = table
+	tags["operation"] = method
+	return q + " " + sqlComment(tags)
+}
+
+// sqlComment formats the given tags as a sqlcommenter comment, like
+// /*key='value',other='value'*/. See https://google.github.io/sqlcommenter/spec/.
+func sqlComment(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("/*")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(commentEscape(k))
+		b.WriteString("='")
+		b.WriteString(commentEscape(tags[k]))
+		b.WriteByte('\'')
+	}
+	b.WriteString("*/")
+	return b.String()
+}
+
+// commentEscape URL encodes s, which also escapes quotes and comment ends.
+func commentEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
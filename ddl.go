@@ -0,0 +1,200 @@
+package schemable
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CreateTableSQL returns the statements that create the Schemer's table in the
+// given dialect: a CREATE TABLE statement, followed by a CREATE INDEX statement
+// for each index.
+//
+// Column types are mapped from the Go field types, unless set with a TYPE tag
+// option. Pointer and sql.Null* fields are NULLable, and other fields are NOT
+// NULL. These tag options are supported after the column name:
+//
+//	TYPE VARCHAR(64)  explicit column type
+//	SIZE 64           VARCHAR or VARBINARY size of string and []byte fields
+//	UNIQUE            unique column
+//	INDEX             index on the column
+//	INDEX name        index on every column with this index name
+//	DEFAULT 'value'   default value, as an SQL literal
+//
+// SQLite only allows an AUTOINCREMENT column as the sole primary key, so an
+// auto increment column is the primary key there, with a UNIQUE constraint on
+// all of the Schemer's primary keys.
+func (s *Schemer[T]) CreateTableSQL(d Dialect) ([]string, error) {
+	if d == UnknownDialect {
+		return nil, fmt.Errorf("schemable: no DDL for %s dialect", d)
+	}
+
+	var auto *field
+	for _, f := range s.fields {
+		if f.isAuto {
+			auto = f
+		}
+	}
+
+	defs := make([]string, 0, len(s.fields)+1)
+	for _, f := range s.fields {
+		def, err := f.columnDef(d, auto == f)
+		if err != nil {
+			return nil, fmt.Errorf("schemable: %s.%s: %w", s.table, f.name, err)
+		}
+		defs = append(defs, def)
+	}
+
+	if len(s.keys) > 0 {
+		keys := make([]string, len(s.keys))
+		for i, k := range s.keys {
+			keys[i] = k.column
+		}
+		switch {
+		case d != SQLite || auto == nil:
+			defs = append(defs, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+		case len(s.keys) > 1 || s.keys[0] != auto:
+			defs = append(defs, "UNIQUE ("+strings.Join(keys, ", ")+")")
+		}
+	}
+
+	stmts := []string{"CREATE TABLE " + s.table + " (\n  " + strings.Join(defs, ",\n  ") + "\n)"}
+
+	var names []string
+	indexes := make(map[string][]string)
+	for _, f := range s.fields {
+		if f.index == "" {
+			continue
+		}
+		if _, ok := indexes[f.index]; !ok {
+			names = append(names, f.index)
+		}
+		indexes[f.index] = append(indexes[f.index], f.column)
+	}
+	for _, name := range names {
+		stmts = append(stmts, fmt.Sprintf("CREATE INDEX %s ON %s (%s)", name, s.table, strings.Join(indexes[name], ", ")))
+	}
+	return stmts, nil
+}
+
+// columnDef returns the column definition for the field. The auto increment
+// column of a SQLite table is its primary key.
+func (f *field) columnDef(d Dialect, auto bool) (string, error) {
+	typ, nullable, err := f.columnType(d)
+	if err != nil {
+		return "", err
+	}
+
+	def := []string{f.column, typ}
+	switch {
+	case auto && d == SQLite:
+		// only an INTEGER PRIMARY KEY can be AUTOINCREMENT
+		def = []string{f.column, "INTEGER PRIMARY KEY AUTOINCREMENT"}
+	case auto && d == MySQL:
+		def = append(def, "NOT NULL AUTO_INCREMENT")
+	case auto && d == Postgres:
+		def = append(def, "GENERATED BY DEFAULT AS IDENTITY")
+	case !nullable || f.isKey:
+		def = append(def, "NOT NULL")
+	}
+	if f.isUnique {
+		def = append(def, "UNIQUE")
+	}
+	if f.defaultValue != "" {
+		def = append(def, "DEFAULT "+f.defaultValue)
+	}
+	return strings.Join(def, " "), nil
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+
+	// sql.Null* types, and the types they wrap
+	nullTypes = map[reflect.Type]reflect.Type{
+		reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+		reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+		reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+		reflect.TypeOf(sql.NullInt16{}):   reflect.TypeOf(int16(0)),
+		reflect.TypeOf(sql.NullByte{}):    reflect.TypeOf(byte(0)),
+		reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+		reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+		reflect.TypeOf(sql.NullTime{}):    timeType,
+	}
+)
+
+// columnType returns the column type of the field in the given dialect, and
+// whether it is NULLable.
+func (f *field) columnType(d Dialect) (string, bool, error) {
+	t := f.kind
+	nullable := false
+	if t.Kind() == reflect.Ptr {
+		t, nullable = t.Elem(), true
+	}
+	if inner, ok := nullTypes[t]; ok {
+		t, nullable = inner, true
+	}
+	if f.sqlType != "" {
+		return f.sqlType, nullable, nil
+	}
+
+	switch {
+	case t == timeType:
+		return dialectType(d, "DATETIME", "DATETIME(6)", "TIMESTAMP WITH TIME ZONE"), nullable, nil
+	case t == bytesType:
+		if f.size > 0 {
+			return dialectType(d, "BLOB", fmt.Sprintf("VARBINARY(%d)", f.size), "BYTEA"), nullable, nil
+		}
+		return dialectType(d, "BLOB", "LONGBLOB", "BYTEA"), nullable, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return dialectType(d, "BOOLEAN", "BOOLEAN", "BOOLEAN"), nullable, nil
+	case reflect.Int8:
+		return dialectType(d, "INTEGER", "TINYINT", "SMALLINT"), nullable, nil
+	case reflect.Int16:
+		return dialectType(d, "INTEGER", "SMALLINT", "SMALLINT"), nullable, nil
+	case reflect.Int32:
+		return dialectType(d, "INTEGER", "INT", "INTEGER"), nullable, nil
+	case reflect.Int, reflect.Int64:
+		return dialectType(d, "INTEGER", "BIGINT", "BIGINT"), nullable, nil
+
+	// Postgres has no unsigned types, so unsigned fields use the next wider
+	// type, or NUMERIC(20) for the full range of a uint64.
+	case reflect.Uint8:
+		return dialectType(d, "INTEGER", "TINYINT UNSIGNED", "SMALLINT"), nullable, nil
+	case reflect.Uint16:
+		return dialectType(d, "INTEGER", "SMALLINT UNSIGNED", "INTEGER"), nullable, nil
+	case reflect.Uint32:
+		return dialectType(d, "INTEGER", "INT UNSIGNED", "BIGINT"), nullable, nil
+	case reflect.Uint, reflect.Uint64:
+		return dialectType(d, "INTEGER", "BIGINT UNSIGNED", "NUMERIC(20)"), nullable, nil
+	case reflect.Float32:
+		return dialectType(d, "REAL", "FLOAT", "REAL"), nullable, nil
+	case reflect.Float64:
+		return dialectType(d, "REAL", "DOUBLE", "DOUBLE PRECISION"), nullable, nil
+	case reflect.String:
+		switch {
+		case f.size > 0:
+			return fmt.Sprintf("VARCHAR(%d)", f.size), nullable, nil
+		case d == MySQL && (f.isKey || f.isUnique || f.index != ""):
+			// MySQL can't index TEXT columns without a prefix length
+			return "VARCHAR(255)", nullable, nil
+		}
+		return "TEXT", nullable, nil
+	}
+	return "", false, fmt.Errorf("no column type for %s, use a TYPE tag option", f.kind)
+}
+
+func dialectType(d Dialect, sqlite, mysql, postgres string) string {
+	switch d {
+	case MySQL:
+		return mysql
+	case Postgres:
+		return postgres
+	}
+	return sqlite
+}
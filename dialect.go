@@ -0,0 +1,133 @@
+package schemable
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"syscall"
+)
+
+// Dialect identifies the SQL database behind a client.
+type Dialect int
+
+const (
+	UnknownDialect Dialect = iota
+	SQLite
+	MySQL
+	Postgres
+)
+
+func (d Dialect) String() string {
+	switch d {
+	case SQLite:
+		return "sqlite"
+	case MySQL:
+		return "mysql"
+	case Postgres:
+		return "postgres"
+	}
+	return "unknown"
+}
+
+// detectDialect returns the Dialect of the given database from the package of
+// its driver.
+func detectDialect(db *sql.DB) Dialect {
+	t := reflect.TypeOf(db.Driver())
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	pkg := t.PkgPath()
+	switch {
+	case strings.Contains(pkg, "sqlite"):
+		return SQLite
+	case strings.Contains(pkg, "mysql"):
+		return MySQL
+	case strings.HasSuffix(pkg, "/pq"), strings.Contains(pkg, "pgx"), strings.Contains(pkg, "postgres"):
+		return Postgres
+	}
+	return UnknownDialect
+}
+
+// IsTransient returns true if the given error is likely to succeed when the
+// query is retried, such as a dropped connection, a deadlock, or a locked
+// database. Timeouts, including network timeouts, are not transient, since a
+// retry could run past the caller's deadline.
+func (d Dialect) IsTransient(err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, ErrTimeout):
+		// deadlines already spent their time, and would start over
+		return false
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNABORTED),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	}
+
+	msg := err.Error()
+	switch d {
+	case SQLite:
+		return containsAny(msg, sqliteTransient)
+	case MySQL:
+		return containsAny(msg, mysqlTransient)
+	case Postgres:
+		var serr interface{ SQLState() string }
+		if errors.As(err, &serr) {
+			return isPostgresTransient(serr.SQLState())
+		}
+		if i := strings.Index(msg, "SQLSTATE "); i >= 0 && len(msg) >= i+14 {
+			return isPostgresTransient(msg[i+9 : i+14])
+		}
+	}
+	return false
+}
+
+var (
+	sqliteTransient = []string{
+		"database is locked",
+		"database table is locked",
+		"SQLITE_BUSY",
+	}
+
+	mysqlTransient = []string{
+		"Error 1040", // too many connections
+		"Error 1205", // lock wait timeout
+		"Error 1213", // deadlock
+		"Error 2006", // server has gone away
+		"Error 2013", // lost connection during query
+		"Error 1290", // read only, during failover
+		"invalid connection",
+	}
+)
+
+// isPostgresTransient checks for connection exceptions, serialization
+// failures, deadlocks, too many connections, and server shutdowns.
+func isPostgresTransient(state string) bool {
+	switch {
+	case strings.HasPrefix(state, "08"):
+		return true
+	case state == "40001", state == "40P01", state == "53300", state == "57P01":
+		return true
+	}
+	return false
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package schemable
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable/internal/sqlstub"
+)
+
+// DryRunClient is a Client that records the statements a Schemer or Recorder
+// would run, without running them. Exec returns a fake sql.Result, Query
+// returns no rows, and QueryRow returns sql.ErrNoRows. Transactions from Begin
+// record their statements with the DryRunClient too.
+type DryRunClient struct {
+	builder *sq.StatementBuilderType
+	mu      sync.Mutex
+	stmts   []Statement
+	inserts int64
+	result  func(stmt Statement) sql.Result
+}
+
+// DryRunResult is a fake sql.Result.
+type DryRunResult struct {
+	InsertID int64
+	Affected int64
+}
+
+// NewDryRun returns a DryRunClient. By default, Exec returns a DryRunResult
+// that affects 1 row, with an increasing InsertID for every INSERT.
+func NewDryRun() *DryRunClient {
+	builder := sq.StatementBuilder
+	return &DryRunClient{builder: &builder}
+}
+
+// SetResult sets the function that returns the sql.Result of each Exec, or
+// resets it to the default if nil. Recorder.Insert fails if fn returns a nil
+// sql.Result for a Schemer with an AUTO INCREMENT field.
+func (c *DryRunClient) SetResult(fn func(stmt Statement) sql.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.result = fn
+}
+
+// Statements returns the recorded statements in the order they were received.
+func (c *DryRunClient) Statements() []Statement {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Statement(nil), c.stmts...)
+}
+
+// Reset removes the recorded statements.
+func (c *DryRunClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stmts = nil
+	c.inserts = 0
+}
+
+// WritePlan writes the recorded statements with their args to w, one numbered
+// statement per line.
+func (c *DryRunClient) WritePlan(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for i, stmt := range c.Statements() {
+		fmt.Fprintf(bw, "%d. %s", i+1, stmt.SQL)
+		if len(stmt.Args) > 0 {
+			args := make([]string, len(stmt.Args))
+			for j, a := range stmt.Args {
+				args[j] = planArg(a)
+			}
+			fmt.Fprintf(bw, " -- [%s]", strings.Join(args, ", "))
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// Exec records the statement and returns its fake sql.Result.
+func (c *DryRunClient) Exec(ctx context.Context, q string, args ...any) (sql.Result, error) {
+	stmt := Statement{Kind: ExecStatement, SQL: q, Args: args}
+
+	c.mu.Lock()
+	c.stmts = append(c.stmts, stmt)
+	fn := c.result
+	res := DryRunResult{Affected: 1}
+	if fn == nil && statementOp(q) == OpInsert {
+		c.inserts++
+		res.InsertID = c.inserts
+	}
+	c.mu.Unlock()
+
+	if fn != nil {
+		return fn(stmt), nil
+	}
+	return res, nil
+}
+
+// Query records the statement and returns empty rows.
+func (c *DryRunClient) Query(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
+	c.record(Statement{Kind: QueryStatement, SQL: q, Args: args})
+	return sqlstub.Rows(nil, nil)
+}
+
+// QueryRow records the statement and returns a *sql.Row with sql.ErrNoRows.
+func (c *DryRunClient) QueryRow(ctx context.Context, q string, args ...any) *sql.Row {
+	c.record(Statement{Kind: QueryRowStatement, SQL: q, Args: args})
+	return sqlstub.ErrRow(nil)
+}
+
+// Begin starts a transaction that records its statements with this client.
+// Commit and Rollback do nothing else. The ReadOnly option rejects writes with
+// ErrReadOnly.
+func (c *DryRunClient) Begin(ctx context.Context, opts *sql.TxOptions) (*TxnClient, error) {
+	// the stub driver does not support TxOptions
+	tx, err := dryRunDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &TxnClient{
+		tx:       tx,
+		builder:  c.builder,
+		readOnly: opts != nil && opts.ReadOnly,
+		parent:   c,
+		settings: settings{logger: eventLogger{c}},
+	}
+	tc.handler = c.handle
+	if tc.readOnly {
+		tc.handler = readOnlyHandler(tc.handler)
+	}
+	return tc, nil
+}
+
+// handle runs a Statement from a dry run transaction.
+func (c *DryRunClient) handle(ctx context.Context, stmt Statement) Response {
+	switch stmt.Kind {
+	case QueryStatement:
+		rows, err := c.Query(ctx, stmt.SQL, stmt.Args...)
+		return Response{Rows: rows, Err: err}
+	case QueryRowStatement:
+		row := c.QueryRow(ctx, stmt.SQL, stmt.Args...)
+		return Response{Row: row, Err: row.Err()}
+	default:
+		res, err := c.Exec(ctx, stmt.SQL, stmt.Args...)
+		return Response{Result: res, Err: err}
+	}
+}
+
+// dryRunDB begins the *sql.Tx of dry run transactions.
+var dryRunDB = sqlstub.DB()
+
+// isDryRun returns true for a DryRunClient, or one of its transactions.
+func isDryRun(c Client) bool {
+	switch c := c.(type) {
+	case *DryRunClient:
+		return true
+	case *TxnClient:
+		_, ok := c.parent.(*DryRunClient)
+		return ok
+	}
+	return false
+}
+
+// Builder is the squirrel query builder for this client.
+func (c *DryRunClient) Builder() *sq.StatementBuilderType {
+	return c.builder
+}
+
+// LogQuery does nothing, since the recorded statements are the log.
+func (c *DryRunClient) LogQuery(ctx context.Context, q string, args []any) {
+}
+
+func (c *DryRunClient) record(stmt Statement) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stmts = append(c.stmts, stmt)
+}
+
+func (r DryRunResult) LastInsertId() (int64, error) {
+	return r.InsertID, nil
+}
+
+func (r DryRunResult) RowsAffected() (int64, error) {
+	return r.Affected, nil
+}
+
+// planArg formats a statement arg for WritePlan.
+func planArg(a any) string {
+	switch v := a.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("%q", v)
+	}
+	return fmt.Sprint(a)
+}
@@ -0,0 +1,145 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// accessorField is a db tagged field of a struct.
+type accessorField struct {
+	name, column string
+	pointer      bool
+}
+
+// Accessors writes a Go source file with schemable.Accessors methods for the
+// given structs in the package in dir, or for every struct with db tags if
+// none are given. Schemers use the methods instead of reflection.
+func Accessors(w io.Writer, dir string, types ...string) error {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return err
+	}
+
+	var pkgName string
+	structs := make(map[string][]accessorField)
+	var names []string
+	for name, pkg := range pkgs {
+		if pkgName != "" {
+			return fmt.Errorf("gen: multiple packages in %s: %s and %s", dir, pkgName, name)
+		}
+		pkgName = name
+
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					st, ok := ts.Type.(*ast.StructType)
+					if !ok || ts.TypeParams != nil {
+						continue
+					}
+					if fields := taggedFields(st); len(fields) > 0 {
+						structs[ts.Name.Name] = fields
+						names = append(names, ts.Name.Name)
+					}
+				}
+			}
+		}
+	}
+	if pkgName == "" {
+		return fmt.Errorf("gen: no Go package in %s", dir)
+	}
+
+	if len(types) > 0 {
+		names = types
+	}
+	sort.Strings(names)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by schemable-accessors. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n", pkgName)
+	for _, name := range names {
+		fields, ok := structs[name]
+		if !ok {
+			return fmt.Errorf("gen: no struct %s with db tags in %s", name, dir)
+		}
+		writeAccessors(&src, name, fields)
+	}
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("gen: formatting source: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// taggedFields returns the fields with db tags, like scanFields in Bind.
+func taggedFields(st *ast.StructType) []accessorField {
+	var fields []accessorField
+	for _, f := range st.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		stag := reflect.StructTag(tag).Get("db")
+		if stag == "" {
+			continue
+		}
+
+		_, pointer := f.Type.(*ast.StarExpr)
+		column := strings.SplitN(stag, ",", 2)[0]
+		for _, name := range f.Names {
+			fields = append(fields, accessorField{name: name.Name, column: column, pointer: pointer})
+		}
+	}
+	return fields
+}
+
+func writeAccessors(w io.Writer, name string, fields []accessorField) {
+	cols := make([]string, len(fields))
+	refs := make([]string, len(fields))
+	vals := make([]string, len(fields))
+	for i, f := range fields {
+		cols[i] = strconv.Quote(f.column)
+		refs[i] = "&t." + f.name
+		vals[i] = "t." + f.name
+	}
+
+	fmt.Fprintf(w, "\n// SchemableColumns returns the columns of %s's db tagged fields.\n", name)
+	fmt.Fprintf(w, "func (t *%s) SchemableColumns() []string {\n", name)
+	fmt.Fprintf(w, "\treturn []string{%s}\n}\n", strings.Join(cols, ", "))
+
+	fmt.Fprintf(w, "\n// SchemableRefs returns pointers to %s's db tagged fields.\n", name)
+	fmt.Fprintf(w, "func (t *%s) SchemableRefs() []any {\n", name)
+	fmt.Fprintf(w, "\treturn []any{%s}\n}\n", strings.Join(refs, ", "))
+
+	fmt.Fprintf(w, "\n// SchemableValues returns the values of %s's db tagged fields.\n", name)
+	fmt.Fprintf(w, "func (t *%s) SchemableValues() []any {\n", name)
+	fmt.Fprintf(w, "\tvals := []any{%s}\n", strings.Join(vals, ", "))
+	for i, f := range fields {
+		if f.pointer {
+			fmt.Fprintf(w, "\tif t.%s != nil {\n\t\tvals[%d] = *t.%s\n\t}\n", f.name, i, f.name)
+		}
+	}
+	fmt.Fprintf(w, "\treturn vals\n}\n")
+}
@@ -0,0 +1,178 @@
+// Package gen generates Go source for schemable, like tagged structs and
+// Schemers from the tables of an existing database.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/refractionist/schemable"
+)
+
+// Options configure the generated source.
+type Options struct {
+	// Package is the name of the generated package, "models" by default.
+	Package string
+
+	// Singular trims plural suffixes from table names for struct names, so the
+	// comic_titles table has a ComicTitle struct and a ComicTitles Schemer.
+	Singular bool
+
+	// TypeNames overrides struct names by table name, and VarNames overrides
+	// Schemer variable names by table name.
+	TypeNames map[string]string
+	VarNames  map[string]string
+
+	// FieldNames overrides field names by "table.column", or by "column" for
+	// every table.
+	FieldNames map[string]string
+
+	// Types overrides Go types by "table.column", by "column", or by database
+	// type like "DATETIME". Types from other packages include the import
+	// path, like "github.com/google/uuid.UUID". Overrides are used as is, so
+	// nullable columns need a pointer or sql.Null* type.
+	Types map[string]string
+}
+
+// Structs writes a Go source file with a tagged struct and a Schemer for each
+// of the given tables. Nullable columns are pointers.
+func Structs(w io.Writer, tables []*schemable.TableSchema, opts Options) error {
+	if opts.Package == "" {
+		opts.Package = "models"
+	}
+
+	imports := map[string]bool{"github.com/refractionist/schemable": true}
+	var body bytes.Buffer
+	for _, ts := range tables {
+		typeName, varName := opts.names(ts.Table)
+		fmt.Fprintf(&body, "\n// %s is a row of the %s table.\n", typeName, ts.Table)
+		fmt.Fprintf(&body, "type %s struct {\n", typeName)
+
+		used := make(map[string]bool, len(ts.Columns))
+		for _, col := range ts.Columns {
+			name := opts.fieldName(ts.Table, col.Name)
+			for i := 2; used[name]; i++ {
+				name = fmt.Sprintf("%s%d", opts.fieldName(ts.Table, col.Name), i)
+			}
+			used[name] = true
+
+			typ, pkg := opts.goType(ts.Table, col)
+			if pkg != "" {
+				imports[pkg] = true
+			}
+			fmt.Fprintf(&body, "\t%s %s `db:\"%s\"`\n", name, typ, tag(col))
+		}
+		fmt.Fprintf(&body, "}\n\n")
+		fmt.Fprintf(&body, "// %s binds %s to the %s table.\n", varName, typeName, ts.Table)
+		fmt.Fprintf(&body, "var %s = schemable.Bind[%s](%q)\n", varName, typeName, ts.Table)
+	}
+
+	// standard library imports go first, like goimports
+	var std, other []string
+	for path := range imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by schemable-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", opts.Package)
+	for _, path := range std {
+		fmt.Fprintf(&src, "\t%q\n", path)
+	}
+	if len(std) > 0 {
+		fmt.Fprintf(&src, "\n")
+	}
+	for _, path := range other {
+		fmt.Fprintf(&src, "\t%q\n", path)
+	}
+	fmt.Fprintf(&src, ")\n")
+	src.Write(body.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("gen: formatting source: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// tag returns the db tag of the column, with its PRIMARY KEY and AUTO
+// INCREMENT options.
+func tag(col schemable.ColumnSchema) string {
+	opts := []string{col.Name}
+	if col.PrimaryKey {
+		opts = append(opts, "PRIMARY KEY")
+	}
+	if col.AutoIncrement {
+		opts = append(opts, "AUTO INCREMENT")
+	}
+	return strings.Join(opts, ", ")
+}
+
+// goType returns the Go type of the column, and the import path it needs.
+func (o Options) goType(table string, col schemable.ColumnSchema) (string, string) {
+	dbType := strings.ToUpper(strings.TrimSpace(col.Type))
+	for _, key := range []string{table + "." + col.Name, col.Name, dbType} {
+		if t, ok := o.Types[key]; ok {
+			return qualify(t)
+		}
+	}
+	if i := strings.IndexByte(dbType, '('); i >= 0 {
+		if t, ok := o.Types[strings.TrimSpace(dbType[:i])]; ok {
+			return qualify(t)
+		}
+	}
+
+	typ, pkg := columnType(dbType)
+	if col.Nullable && typ != "[]byte" {
+		typ = "*" + typ
+	}
+	return typ, pkg
+}
+
+// columnType maps a database type to a Go type, with rules like SQLite's type
+// affinity.
+func columnType(dbType string) (string, string) {
+	switch {
+	case strings.HasPrefix(dbType, "BOOL"), dbType == "TINYINT(1)", dbType == "BIT(1)":
+		return "bool", ""
+	case strings.Contains(dbType, "INT") && !strings.Contains(dbType, "POINT") && !strings.Contains(dbType, "INTERVAL"):
+		return "int64", ""
+	case strings.Contains(dbType, "CHAR"), strings.Contains(dbType, "CLOB"), strings.Contains(dbType, "TEXT"),
+		strings.HasPrefix(dbType, "ENUM"), dbType == "UUID":
+		return "string", ""
+	case strings.Contains(dbType, "BLOB"), strings.Contains(dbType, "BINARY"), dbType == "BYTEA":
+		return "[]byte", ""
+	case strings.Contains(dbType, "REAL"), strings.Contains(dbType, "FLOA"), strings.Contains(dbType, "DOUB"),
+		strings.HasPrefix(dbType, "DEC"), strings.HasPrefix(dbType, "NUMERIC"):
+		return "float64", ""
+	case strings.Contains(dbType, "DATE"), strings.Contains(dbType, "TIME"):
+		return "time.Time", "time"
+	}
+	return "[]byte", ""
+}
+
+// qualify returns the Go type expression and import path of a type like
+// "*github.com/google/uuid.UUID".
+func qualify(t string) (string, string) {
+	prefix := t[:len(t)-len(strings.TrimLeft(t, "*[]"))]
+	t = t[len(prefix):]
+
+	slash := strings.LastIndexByte(t, '/')
+	dot := strings.LastIndexByte(t, '.')
+	if dot <= slash {
+		return prefix + t, ""
+	}
+	path := t[:dot]
+	return prefix + path[slash+1:] + t[dot:], path
+}
@@ -0,0 +1,89 @@
+package gen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// initialisms are upper cased in Go names, like ID and URL.
+var initialisms = map[string]bool{
+	"API": true, "CSS": true, "DNS": true, "HTML": true, "HTTP": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "TCP": true,
+	"TLS": true, "TTL": true, "UID": true, "URI": true, "URL": true,
+	"UTF8": true, "UUID": true, "XML": true,
+}
+
+// names returns the struct and Schemer variable names for the table.
+func (o Options) names(table string) (string, string) {
+	varName := camel(table)
+	typeName := varName
+	if o.Singular {
+		typeName = camel(singular(table))
+	}
+	if n, ok := o.TypeNames[table]; ok {
+		typeName = n
+	}
+	if n, ok := o.VarNames[table]; ok {
+		varName = n
+	}
+	if varName == typeName {
+		varName += "Table"
+	}
+	return typeName, varName
+}
+
+// fieldName returns the struct field name of the column.
+func (o Options) fieldName(table, column string) string {
+	if n, ok := o.FieldNames[table+"."+column]; ok {
+		return n
+	}
+	if n, ok := o.FieldNames[column]; ok {
+		return n
+	}
+	return camel(column)
+}
+
+// camel converts a snake case name to an exported Go name, like IDTwo for
+// id_two.
+func camel(name string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if u := strings.ToUpper(w); initialisms[u] {
+			b.WriteString(u)
+			continue
+		}
+		b.WriteString(strings.ToUpper(w[:1]) + w[1:])
+	}
+
+	s := b.String()
+	if s == "" || !unicode.IsLetter(rune(s[0])) {
+		s = "X" + s
+	}
+	return s
+}
+
+// uncountable words are the same in singular and plural.
+var uncountable = []string{"series", "species", "news", "data", "info", "metadata", "status"}
+
+// singular trims a plural suffix from the last word of the name.
+func singular(name string) string {
+	for _, w := range uncountable {
+		if strings.HasSuffix(name, w) {
+			return name
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"),
+		strings.HasSuffix(name, "shes"):
+		return name[:len(name)-2]
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && !strings.HasSuffix(name, "us"):
+		return name[:len(name)-1]
+	}
+	return name
+}
@@ -0,0 +1,55 @@
+package schemable
+
+import "sync"
+
+// OnCommit adds a func to run after the transaction commits. Funcs run in the
+// order they were added, and not at all if the transaction has ended.
+func (c *TxnClient) OnCommit(fn func()) {
+	c.hooks.add(fn, true)
+}
+
+// OnRollback adds a func to run after the transaction rolls back, or fails to
+// commit. Funcs run in the order they were added, and not at all if the
+// transaction has ended.
+func (c *TxnClient) OnRollback(fn func()) {
+	c.hooks.add(fn, false)
+}
+
+// txnHooks are the funcs that run once a transaction ends.
+type txnHooks struct {
+	mu       sync.Mutex
+	commit   []func()
+	rollback []func()
+	done     bool
+}
+
+func (h *txnHooks) add(fn func(), commit bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	switch {
+	case h.done:
+	case commit:
+		h.commit = append(h.commit, fn)
+	default:
+		h.rollback = append(h.rollback, fn)
+	}
+}
+
+// run runs the commit or rollback funcs, the first time it is called.
+func (h *txnHooks) run(committed bool) {
+	h.mu.Lock()
+	if h.done {
+		h.mu.Unlock()
+		return
+	}
+	fns := h.rollback
+	if committed {
+		fns = h.commit
+	}
+	h.done, h.commit, h.rollback = true, nil, nil
+	h.mu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+}
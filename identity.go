@@ -0,0 +1,259 @@
+package schemable
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// IdentityMap caches the Targets loaded with a context from WithIdentityMap,
+// by table and primary keys, so that Schemer.Find, Schemer.First and
+// Recorder.Load return the same Target instance for a row. Recorder.Update and
+// Recorder.Delete drop the row's Target, and Schemer.DeleteWhere drops the
+// whole table. Targets loaded in a transaction are dropped if it rolls back.
+type IdentityMap struct {
+	mu      sync.Mutex
+	targets map[identity]any
+	// rows loaded in each open transaction
+	txns map[*TxnClient][]identity
+}
+
+type identity struct {
+	table, ids string
+}
+
+// WithIdentityMap returns a modified variant of the given context with an
+// empty IdentityMap. Use one per request, since Targets are cached until the
+// context is discarded.
+func WithIdentityMap(ctx context.Context) context.Context {
+	return context.WithValue(ctx, identityKey, &IdentityMap{
+		targets: make(map[identity]any),
+		txns:    make(map[*TxnClient][]identity),
+	})
+}
+
+// IdentityMapFrom fetches the embedded IdentityMap from the given context, or
+// nil if there is none.
+func IdentityMapFrom(ctx context.Context) *IdentityMap {
+	m, _ := ctx.Value(identityKey).(*IdentityMap)
+	return m
+}
+
+// Len returns the number of cached Targets.
+func (m *IdentityMap) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.targets)
+}
+
+// Clear drops every cached Target.
+func (m *IdentityMap) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.targets = make(map[identity]any)
+}
+
+// Find returns a *Recorder[T] of the row with the given primary key values, in
+// the order of the key fields. Each value must have the key field's type, or
+// be an integer that fits in it. With an IdentityMap in the context, it returns
+// the cached Target without a query if the row was already loaded. The context
+// must have a client embedded with WithClient().
+func (s *Schemer[T]) Find(ctx context.Context, ids ...any) (*Recorder[T], error) {
+	if len(ids) != len(s.keys) {
+		return nil, fmt.Errorf("schemable: %s has %d primary keys, got %d", s.table, len(s.keys), len(ids))
+	}
+
+	rec := s.Record(nil)
+	rt := reflect.ValueOf(rec.Target).Elem()
+	for i, k := range s.keys {
+		fv := rt.FieldByName(k.name)
+		v, ok := keyValue(reflect.ValueOf(ids[i]), fv.Type())
+		if !ok {
+			return nil, fmt.Errorf("schemable: cannot use %T as %s.%s", ids[i], s.table, k.column)
+		}
+		fv.Set(v)
+	}
+
+	if err := rec.Load(ctx); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+// keyValue returns v as a value of type t, if it has that exact type or is an
+// integer that fits in t. Other conversions, like an int to a string or a
+// float to an int, would find the wrong row.
+func keyValue(v reflect.Value, t reflect.Type) (reflect.Value, bool) {
+	switch {
+	case !v.IsValid():
+		return v, false
+	case v.Type() == t:
+		return v, true
+	}
+
+	kv := reflect.New(t).Elem()
+	switch {
+	case isInt(v.Kind()) && isInt(t.Kind()):
+		n := v.Int()
+		if kv.OverflowInt(n) {
+			return v, false
+		}
+		kv.SetInt(n)
+	case isInt(v.Kind()) && isUint(t.Kind()):
+		n := v.Int()
+		if n < 0 || kv.OverflowUint(uint64(n)) {
+			return v, false
+		}
+		kv.SetUint(uint64(n))
+	case isUint(v.Kind()) && isInt(t.Kind()):
+		n := v.Uint()
+		if n > math.MaxInt64 || kv.OverflowInt(int64(n)) {
+			return v, false
+		}
+		kv.SetInt(int64(n))
+	case isUint(v.Kind()) && isUint(t.Kind()):
+		n := v.Uint()
+		if kv.OverflowUint(n) {
+			return v, false
+		}
+		kv.SetUint(n)
+	default:
+		return v, false
+	}
+	return kv, true
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+// identityMap returns the context's IdentityMap, or nil if there is none or
+// the Schemer has no primary keys to identify rows with.
+func (s *Schemer[T]) identityMap(ctx context.Context) *IdentityMap {
+	if len(s.keys) == 0 {
+		return nil
+	}
+	return IdentityMapFrom(ctx)
+}
+
+// cached sets the Recorder Target to the cached one for its row, returning
+// false if there is none.
+func (r *Recorder[T]) cached(ctx context.Context) bool {
+	m := r.Schemer.identityMap(ctx)
+	if m == nil {
+		return false
+	}
+
+	m.mu.Lock()
+	tgt, ok := m.targets[r.identity()].(*T)
+	m.mu.Unlock()
+	if ok {
+		r.useCached(tgt)
+	}
+	return ok
+}
+
+// remember caches the Recorder Target for its row, or sets it to the one
+// already cached.
+func (r *Recorder[T]) remember(ctx context.Context) {
+	m := r.Schemer.identityMap(ctx)
+	if m == nil {
+		return
+	}
+
+	id := r.identity()
+	m.mu.Lock()
+	if tgt, ok := m.targets[id].(*T); ok {
+		m.mu.Unlock()
+		r.useCached(tgt)
+		return
+	}
+	m.targets[id] = r.Target
+
+	// forget rows loaded in a transaction that rolls back
+	tx, _ := ClientFrom(ctx).(*TxnClient)
+	if tx == nil {
+		m.mu.Unlock()
+		return
+	}
+	_, open := m.txns[tx]
+	m.txns[tx] = append(m.txns[tx], id)
+	m.mu.Unlock()
+
+	if !open {
+		tx.OnCommit(func() { m.endTxn(tx, false) })
+		tx.OnRollback(func() { m.endTxn(tx, true) })
+	}
+}
+
+// useCached copies the cached Target into the Recorder's own Target, so that
+// the caller's struct is filled too, then sets the Recorder Target to the
+// cached one.
+func (r *Recorder[T]) useCached(tgt *T) {
+	if r.Target != tgt {
+		*r.Target = *tgt
+	}
+	r.Target = tgt
+	r.setValues()
+}
+
+// forget drops the cached Target for the Recorder's row.
+func (r *Recorder[T]) forget(ctx context.Context) {
+	if m := r.Schemer.identityMap(ctx); m != nil {
+		m.mu.Lock()
+		delete(m.targets, r.identity())
+		m.mu.Unlock()
+	}
+}
+
+// forgetTable drops the cached Targets for every row in the Schemer's table.
+func (s *Schemer[T]) forgetTable(ctx context.Context) {
+	m := s.identityMap(ctx)
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.targets {
+		if id.table == s.table {
+			delete(m.targets, id)
+		}
+	}
+}
+
+// endTxn stops tracking the transaction's rows, dropping them if it rolled
+// back.
+func (m *IdentityMap) endTxn(tx *TxnClient, rollback bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rollback {
+		for _, id := range m.txns[tx] {
+			delete(m.targets, id)
+		}
+	}
+	delete(m.txns, tx)
+}
+
+// identity returns the table and primary key values of the Recorder's row.
+func (r *Recorder[T]) identity() identity {
+	return identity{table: r.Schemer.table, ids: strings.Join(r.keyStrings(), "\x00")}
+}
+
+// keyStrings returns the Recorder Target's primary key values as strings, in
+// the order of the key fields.
+func (r *Recorder[T]) keyStrings() []string {
+	where := r.WhereIDs()
+	ids := make([]string, len(r.Schemer.keys))
+	for i, k := range r.Schemer.keys {
+		ids[i] = fmt.Sprint(reflect.Indirect(reflect.ValueOf(where[k.selectcolumn])))
+	}
+	return ids
+}
@@ -0,0 +1,158 @@
+// Package sqlstub builds database/sql values without a real database.
+package sqlstub
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrRow returns a *sql.Row whose Scan and Err methods return err. A nil err
+// is reported as sql.ErrNoRows.
+func ErrRow(err error) *sql.Row {
+	if err == nil {
+		err = sql.ErrNoRows
+	}
+	return stubDB.QueryRowContext(withResult(&result{err: err}), "")
+}
+
+// Rows returns *sql.Rows that scan the given values, one slice per row, in
+// the order of the given columns. Values must be convertible to driver
+// values.
+func Rows(columns []string, values [][]any) (*sql.Rows, error) {
+	r, err := newResult(columns, values)
+	if err != nil {
+		return nil, err
+	}
+	return stubDB.QueryContext(withResult(r), "")
+}
+
+// Row returns a *sql.Row that scans the first of the given values, or returns
+// sql.ErrNoRows if there are none.
+func Row(columns []string, values [][]any) *sql.Row {
+	r, err := newResult(columns, values)
+	if err != nil {
+		return ErrRow(err)
+	}
+	return stubDB.QueryRowContext(withResult(r), "")
+}
+
+// DB returns a *sql.DB that fails every query, for clients that answer
+// queries without a database. Transactions begin and end without error.
+func DB() *sql.DB {
+	return sql.OpenDB(connector{})
+}
+
+var stubDB = DB()
+
+type resultKey struct{}
+
+// result is the outcome of the next query on a stub connection.
+type result struct {
+	columns []string
+	values  [][]driver.Value
+	err     error
+}
+
+func newResult(columns []string, values [][]any) (*result, error) {
+	r := &result{columns: columns, values: make([][]driver.Value, len(values))}
+	for i, row := range values {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("sqlstub: row %d has %d values for %d columns", i, len(row), len(columns))
+		}
+		r.values[i] = make([]driver.Value, len(row))
+		for j, v := range row {
+			dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("sqlstub: column %q of row %d: %w", columns[j], i, err)
+			}
+			r.values[i][j] = dv
+		}
+	}
+	return r, nil
+}
+
+func withResult(r *result) context.Context {
+	return context.WithValue(context.Background(), resultKey{}, r)
+}
+
+// connector opens connections that answer every query with the result found
+// in the query's context.
+type connector struct{}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return conn{}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return stubDriver{}
+}
+
+type stubDriver struct{}
+
+func (d stubDriver) Open(name string) (driver.Conn, error) {
+	return conn{}, nil
+}
+
+type conn struct{}
+
+func (c conn) QueryContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	r, ok := ctx.Value(resultKey{}).(*result)
+	if !ok {
+		return nil, errUnsupported
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return &rows{columns: r.columns, values: r.values}, nil
+}
+
+func (c conn) Prepare(q string) (driver.Stmt, error) {
+	return nil, errUnsupported
+}
+
+func (c conn) Close() error {
+	return nil
+}
+
+func (c conn) Begin() (driver.Tx, error) {
+	return tx{}, nil
+}
+
+type tx struct{}
+
+func (t tx) Commit() error {
+	return nil
+}
+
+func (t tx) Rollback() error {
+	return nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+var errUnsupported = errors.New("sqlstub: unsupported operation")
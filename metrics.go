@@ -0,0 +1,208 @@
+package schemable
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of the latency histogram buckets used by
+// NewMetrics if none are given.
+var DefaultBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// Metrics collects query counts, error counts, and latency histograms for each
+// operation and table. Attach it to a DBClient with SetMetrics. It renders the
+// Prometheus text exposition format as an http.Handler.
+type Metrics struct {
+	buckets []time.Duration
+	mu      sync.Mutex
+	stats   map[metricKey]*QueryStats
+}
+
+type metricKey struct {
+	op    Op
+	table string
+}
+
+// QueryStats are the collected metrics of an operation on a table.
+type QueryStats struct {
+	Op     Op
+	Table  string
+	Count  uint64
+	Errors uint64
+	// Sum is the total duration of all queries.
+	Sum time.Duration
+	// Buckets are the cumulative query counts for each bucket's upper bound.
+	Buckets []BucketCount
+}
+
+// BucketCount is the count of queries that took at most UpperBound.
+type BucketCount struct {
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// NewMetrics returns a Metrics collector with the given latency histogram
+// bucket upper bounds, or DefaultBuckets if none are given.
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]time.Duration(nil), buckets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return &Metrics{buckets: sorted, stats: make(map[metricKey]*QueryStats)}
+}
+
+// LogQueryEvent records the given QueryEvent. Events without an Op, such as
+// those from Client#LogQuery, take it from the SQL statement.
+func (m *Metrics) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+	op := e.Op
+	if op == "" {
+		op = statementOp(e.SQL)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := metricKey{op: op, table: e.Table}
+	s, ok := m.stats[key]
+	if !ok {
+		s = &QueryStats{Op: op, Table: e.Table, Buckets: make([]BucketCount, len(m.buckets))}
+		for i, b := range m.buckets {
+			s.Buckets[i].UpperBound = b
+		}
+		m.stats[key] = s
+	}
+
+	s.Count++
+	s.Sum += e.Duration
+	if e.Err != nil {
+		s.Errors++
+	}
+	for i := range s.Buckets {
+		if e.Duration <= s.Buckets[i].UpperBound {
+			s.Buckets[i].Count++
+		}
+	}
+}
+
+// Snapshot returns a copy of the collected stats, sorted by table and
+// operation.
+func (m *Metrics) Snapshot() []QueryStats {
+	m.mu.Lock()
+	stats := make([]QueryStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		cp := *s
+		cp.Buckets = append([]BucketCount(nil), s.Buckets...)
+		stats = append(stats, cp)
+	}
+	m.mu.Unlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Table != stats[j].Table {
+			return stats[i].Table < stats[j].Table
+		}
+		return stats[i].Op < stats[j].Op
+	})
+	return stats
+}
+
+// Reset discards the collected stats.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stats = make(map[metricKey]*QueryStats)
+}
+
+// ServeHTTP renders the collected stats in the Prometheus text exposition
+// format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WritePrometheus(w)
+}
+
+// WritePrometheus writes the collected stats in the Prometheus text exposition
+// format.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	stats := m.Snapshot()
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "# HELP schemable_queries_total Total number of queries.")
+	fmt.Fprintln(bw, "# TYPE schemable_queries_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(bw, "schemable_queries_total{%s} %d\n", s.labels(), s.Count)
+	}
+
+	fmt.Fprintln(bw, "# HELP schemable_query_errors_total Total number of failed queries.")
+	fmt.Fprintln(bw, "# TYPE schemable_query_errors_total counter")
+	for _, s := range stats {
+		fmt.Fprintf(bw, "schemable_query_errors_total{%s} %d\n", s.labels(), s.Errors)
+	}
+
+	fmt.Fprintln(bw, "# HELP schemable_query_duration_seconds Query latency in seconds.")
+	fmt.Fprintln(bw, "# TYPE schemable_query_duration_seconds histogram")
+	for _, s := range stats {
+		labels := s.labels()
+		for _, b := range s.Buckets {
+			fmt.Fprintf(bw, "schemable_query_duration_seconds_bucket{%s,le=%q} %d\n",
+				labels, formatSeconds(b.UpperBound), b.Count)
+		}
+		fmt.Fprintf(bw, "schemable_query_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, s.Count)
+		fmt.Fprintf(bw, "schemable_query_duration_seconds_sum{%s} %s\n", labels, formatSeconds(s.Sum))
+		fmt.Fprintf(bw, "schemable_query_duration_seconds_count{%s} %d\n", labels, s.Count)
+	}
+
+	return bw.Flush()
+}
+
+func (s QueryStats) labels() string {
+	return `op="` + escapeLabel(string(s.Op)) + `",table="` + escapeLabel(s.Table) + `"`
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
+
+// statementOp returns the Op of the given SQL statement from its first
+// keyword.
+func statementOp(q string) Op {
+	q = strings.TrimLeft(q, " \t\r\n(")
+	if i := strings.IndexAny(q, " \t\r\n("); i > 0 {
+		q = q[:i]
+	}
+
+	switch strings.ToLower(q) {
+	case "select", "with":
+		return OpSelect
+	case "insert", "replace":
+		return OpInsert
+	case "update":
+		return OpUpdate
+	case "delete":
+		return OpDelete
+	}
+	return Op(strings.ToLower(q))
+}
@@ -0,0 +1,86 @@
+package schemable
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/refractionist/schemable/internal/sqlstub"
+)
+
+// StatementKind identifies the Client method that runs a Statement.
+type StatementKind int
+
+const (
+	// ExecStatement is run by Client#Exec.
+	ExecStatement StatementKind = iota
+	// QueryStatement is run by Client#Query.
+	QueryStatement
+	// QueryRowStatement is run by Client#QueryRow.
+	QueryRowStatement
+)
+
+// Statement is a query passing through a Handler chain.
+type Statement struct {
+	Kind StatementKind
+	SQL  string
+	Args []any
+}
+
+// Response is the outcome of a Statement. Only the field matching the
+// Statement's Kind is set, along with Err. A QueryRowStatement Response with
+// a nil Row reports Err from the Row's Scan method.
+type Response struct {
+	Result sql.Result
+	Rows   *sql.Rows
+	Row    *sql.Row
+	Err    error
+}
+
+// Handler runs a Statement against the database.
+type Handler func(ctx context.Context, stmt Statement) Response
+
+// Middleware wraps a Handler to add behavior around Exec, Query, and QueryRow,
+// such as tracing, metrics, retries, query rewriting, or blocking rules.
+type Middleware func(next Handler) Handler
+
+// conn is the query interface shared by *sql.DB and *sql.Tx.
+type conn interface {
+	ExecContext(ctx context.Context, q string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, q string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, q string, args ...any) *sql.Row
+}
+
+// connHandler returns the Handler at the end of a chain, which runs
+// Statements on the given *sql.DB or *sql.Tx.
+func connHandler(db conn) Handler {
+	return func(ctx context.Context, stmt Statement) Response {
+		switch stmt.Kind {
+		case QueryStatement:
+			rows, err := db.QueryContext(ctx, stmt.SQL, stmt.Args...)
+			return Response{Rows: rows, Err: err}
+		case QueryRowStatement:
+			row := db.QueryRowContext(ctx, stmt.SQL, stmt.Args...)
+			return Response{Row: row, Err: row.Err()}
+		default:
+			res, err := db.ExecContext(ctx, stmt.SQL, stmt.Args...)
+			return Response{Result: res, Err: err}
+		}
+	}
+}
+
+// chain wraps h with the given middleware, so that the first middleware is the
+// outermost.
+func chain(mw []Middleware, h Handler) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// rowFrom returns the *sql.Row of a QueryRowStatement Response.
+func rowFrom(res Response) *sql.Row {
+	if res.Row != nil {
+		return res.Row
+	}
+	return sqlstub.ErrRow(res.Err)
+}
@@ -0,0 +1,205 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/refractionist/schemable"
+)
+
+// FromFS loads SQL migrations from the files in the given directory of fsys,
+// named with a version, a name, and a direction:
+//
+//	0001_create_comics.up.sql
+//	0001_create_comics.down.sql
+//	0002_add_volume.sql
+//
+// Files without a direction are up migrations. Each file may have several
+// statements separated by semicolons, which run in order.
+func FromFS(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	var migrations []*Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		base, up := strings.TrimSuffix(name, ".sql"), true
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			base = strings.TrimSuffix(base, ".up")
+		case strings.HasSuffix(base, ".down"):
+			base, up = strings.TrimSuffix(base, ".down"), false
+		}
+
+		digits := len(base) - len(strings.TrimLeft(base, "0123456789"))
+		if digits == 0 {
+			return nil, fmt.Errorf("migrate: %s has no version", name)
+		}
+		v, err := strconv.ParseInt(base[:digits], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: %w", name, err)
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		mg, ok := byVersion[v]
+		if !ok {
+			mg = &Migration{Version: v, Name: strings.TrimLeft(base[digits:], "_-. ")}
+			byVersion[v] = mg
+			migrations = append(migrations, mg)
+		}
+
+		fn := sqlFunc(string(b))
+		switch {
+		case up && mg.Up != nil, !up && mg.Down != nil:
+			return nil, fmt.Errorf("migrate: duplicate migration for version %d: %s", v, name)
+		case up:
+			mg.Up = fn
+		default:
+			mg.Down = fn
+		}
+	}
+	return migrations, nil
+}
+
+// sqlFunc returns a migration func that runs the statements in the given SQL.
+func sqlFunc(script string) func(ctx context.Context, c schemable.Client) error {
+	stmts := SplitStatements(script)
+	return func(ctx context.Context, c schemable.Client) error {
+		for _, stmt := range stmts {
+			if err := Exec(ctx, c, stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SplitStatements splits the SQL script on semicolons that are not in quotes,
+// Postgres dollar quotes like $body$ ... $body$, comments, or the BEGIN ... END
+// body of a CREATE TRIGGER, PROCEDURE, or FUNCTION statement, returning the
+// non-empty statements without their semicolons. A backslash escapes the next
+// character in single and double quotes, like 'it\'s' in MySQL.
+func SplitStatements(script string) []string {
+	var stmts []string
+	var b strings.Builder
+	var first, prev string
+	routine, depth := false, 0
+	flush := func() {
+		if s := strings.TrimSpace(b.String()); s != "" && !onlyComments(s) {
+			stmts = append(stmts, s)
+		}
+		b.Reset()
+		first, prev, routine, depth = "", "", false, 0
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(script) && script[end] != c {
+				if script[end] == '\\' && c != '`' {
+					end++
+				}
+				end++
+			}
+			if end < len(script) {
+				end++
+			}
+			if end > len(script) {
+				end = len(script)
+			}
+			b.WriteString(script[i:end])
+			i = end - 1
+		case c == '$' && dollarTagRe.MatchString(script[i:]):
+			tag := dollarTagRe.FindString(script[i:])
+			end := strings.Index(script[i+len(tag):], tag)
+			if end < 0 {
+				end = len(script) - i
+			} else {
+				end += 2 * len(tag)
+			}
+			b.WriteString(script[i : i+end])
+			i += end - 1
+		case strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = len(script) - i
+			}
+			b.WriteString(script[i : i+end])
+			i += end - 1
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = len(script) - i - 4
+			}
+			b.WriteString(script[i : i+end+4])
+			i += end + 3
+		case c == ';' && depth > 0:
+			b.WriteByte(c)
+		case c == ';':
+			flush()
+		case isWordByte(c) && (i == 0 || !isWordByte(script[i-1])):
+			end := i + 1
+			for end < len(script) && isWordByte(script[end]) {
+				end++
+			}
+			word := strings.ToUpper(script[i:end])
+			switch {
+			case first == "":
+				first = word
+			case first == "CREATE" && (word == "TRIGGER" || word == "PROCEDURE" || word == "FUNCTION"):
+				routine = true
+			case !routine:
+			case word == "BEGIN", word == "CASE" && prev != "END":
+				depth++
+			case word == "END" && depth > 0:
+				depth--
+			case prev == "END" && (word == "IF" || word == "LOOP" || word == "WHILE" || word == "REPEAT"):
+				// MySQL's END IF closes an IF, not a BEGIN
+				depth++
+			}
+			prev = word
+			b.WriteString(script[i:end])
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// onlyComments returns true if the statement is made of line comments.
+func onlyComments(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// dollarTagRe matches the opening tag of a Postgres dollar quoted string, but
+// not a $1 placeholder.
+var dollarTagRe = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
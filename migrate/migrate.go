@@ -0,0 +1,367 @@
+// Package migrate applies versioned SQL or Go migrations through a schemable
+// DBClient, tracking the applied versions in a schema table.
+//
+//	//go:embed migrations/*.sql
+//	var migrations embed.FS
+//
+//	ms, err := migrate.FromFS(migrations, "migrations")
+//	m := migrate.New(client, ms...)
+//	err = m.Up(ctx)
+package migrate
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+// DefaultTable is the default name of the table of applied versions.
+const DefaultTable = "schema_migrations"
+
+// Migration is a versioned change to the database schema.
+type Migration struct {
+	// Version orders migrations, and must be unique and not negative.
+	Version int64
+	Name    string
+
+	// Up applies the migration, and Down reverts it. The client is a
+	// *schemable.TxnClient if the migration runs in a transaction, and is
+	// embedded in the context with schemable.WithClient.
+	Up   func(ctx context.Context, c schemable.Client) error
+	Down func(ctx context.Context, c schemable.Client) error
+
+	// NoTx runs the migration outside of a transaction, for statements like
+	// Postgres's CREATE INDEX CONCURRENTLY.
+	NoTx bool
+}
+
+// Status is the state of a migration.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Missing is true for applied versions with no known Migration.
+	Missing bool
+}
+
+// ErrNoDown is returned when reverting a migration without a Down func.
+var ErrNoDown = errors.New("migrate: no down migration")
+
+// Migrator applies migrations with a DBClient.
+type Migrator struct {
+	client     *schemable.DBClient
+	migrations []*Migration
+	versions   *schemable.Schemer[version]
+}
+
+// version is a row of the schema table.
+type version struct {
+	Version   int64     `db:"version, PRIMARY KEY"`
+	Name      string    `db:"name, SIZE 255"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// New returns a Migrator for the given client and migrations, tracking
+// versions in the DefaultTable.
+func New(c *schemable.DBClient, migrations ...*Migration) *Migrator {
+	m := &Migrator{client: c}
+	m.SetTable(DefaultTable)
+	m.Add(migrations...)
+	return m
+}
+
+// SetTable sets the name of the table of applied versions.
+func (m *Migrator) SetTable(name string) {
+	m.versions = schemable.Bind[version](name)
+}
+
+// Add adds migrations, keeping them ordered by version.
+func (m *Migrator) Add(migrations ...*Migration) {
+	m.migrations = append(m.migrations, migrations...)
+	sort.SliceStable(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+}
+
+// Migrations returns the migrations ordered by version.
+func (m *Migrator) Migrations() []*Migration {
+	return append([]*Migration(nil), m.migrations...)
+}
+
+// Up applies every pending migration in order.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.UpTo(ctx, -1)
+}
+
+// UpTo applies pending migrations in order, up to and including the given
+// version. A negative version applies every pending migration.
+func (m *Migrator) UpTo(ctx context.Context, target int64) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mg := range m.migrations {
+		if target >= 0 && mg.Version > target {
+			break
+		}
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, mg, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Down reverts the most recently applied migration, if any.
+func (m *Migrator) Down(ctx context.Context) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.run(ctx, m.migrations[i], false)
+		}
+	}
+	return nil
+}
+
+// DownTo reverts applied migrations in reverse order, until the given version
+// is the latest applied. A negative version reverts every migration.
+func (m *Migrator) DownTo(ctx context.Context, target int64) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mg := m.migrations[i]
+		if target >= 0 && mg.Version <= target {
+			break
+		}
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+		if err := m.run(ctx, mg, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Status returns the state of every migration, and of applied versions with
+// no known migration, ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		st := Status{Version: mg.Version, Name: mg.Name}
+		if v, ok := applied[mg.Version]; ok {
+			st.Applied, st.AppliedAt = true, v.AppliedAt
+			delete(applied, mg.Version)
+		}
+		statuses = append(statuses, st)
+	}
+	for _, v := range applied {
+		statuses = append(statuses, Status{
+			Version:   v.Version,
+			Name:      v.Name,
+			Applied:   true,
+			AppliedAt: v.AppliedAt,
+			Missing:   true,
+		})
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied.
+func (m *Migrator) Pending(ctx context.Context) ([]*Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []*Migration
+	for _, mg := range m.migrations {
+		if _, ok := applied[mg.Version]; !ok {
+			pending = append(pending, mg)
+		}
+	}
+	return pending, nil
+}
+
+// run applies or reverts the migration, recording its version. It runs in a
+// transaction unless the migration or the dialect does not allow it.
+func (m *Migrator) run(ctx context.Context, mg *Migration, up bool) error {
+	fn, verb := mg.Up, "up"
+	if !up {
+		fn, verb = mg.Down, "down"
+	}
+	if mg.Version < 0 {
+		return fmt.Errorf("migrate: %d %s: negative version", mg.Version, mg.Name)
+	}
+	if fn == nil {
+		if up {
+			return fmt.Errorf("migrate: %d %s: no up migration", mg.Version, mg.Name)
+		}
+		return fmt.Errorf("%w for %d %s", ErrNoDown, mg.Version, mg.Name)
+	}
+
+	var c schemable.Client = m.client
+	var tc *schemable.TxnClient
+	if !mg.NoTx && transactionalDDL(m.client.Dialect()) {
+		var err error
+		if tc, err = m.client.Begin(ctx, nil); err != nil {
+			return err
+		}
+		c = tc
+	}
+
+	ctx = schemable.WithClient(ctx, c)
+	err := fn(ctx, c)
+	if err == nil {
+		rec := m.versions.Record(&version{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now().UTC()})
+		if up {
+			err = rec.Insert(ctx)
+		} else {
+			err = rec.Delete(ctx)
+		}
+	}
+
+	if tc != nil {
+		if err != nil {
+			tc.Rollback()
+		} else {
+			err = tc.Commit()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("migrate: %s %d %s: %w", verb, mg.Version, mg.Name, err)
+	}
+	return nil
+}
+
+// applied returns the applied versions, creating the schema table if needed.
+func (m *Migrator) applied(ctx context.Context) (map[int64]*version, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx = schemable.WithClient(ctx, m.client)
+	recs, err := m.versions.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+		return q
+	})
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]*version, len(recs))
+	for _, r := range recs {
+		applied[r.Target.Version] = r.Target
+	}
+	return applied, nil
+}
+
+func (m *Migrator) createTable(ctx context.Context) error {
+	stmts, err := m.versions.CreateTableSQL(m.client.Dialect())
+	if err != nil {
+		return err
+	}
+	q := strings.Replace(stmts[0], "CREATE TABLE ", "CREATE TABLE IF NOT EXISTS ", 1)
+	return Exec(ctx, m.client, q)
+}
+
+// lock holds a lock on the schema table's name until the returned func is
+// called, so that Migrators in other processes wait instead of applying the
+// same migrations. It uses an advisory lock on Postgres and GET_LOCK on MySQL,
+// on a connection of its own. SQLite databases are not locked.
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	var lockSQL, unlockSQL string
+	switch m.client.Dialect() {
+	case schemable.Postgres:
+		lockSQL, unlockSQL = "SELECT true FROM pg_advisory_lock(hashtext($1))", "SELECT pg_advisory_unlock(hashtext($1))"
+	case schemable.MySQL:
+		lockSQL, unlockSQL = "SELECT GET_LOCK(?, -1) = 1", "SELECT RELEASE_LOCK(?)"
+	default:
+		return func() {}, nil
+	}
+
+	conn, err := m.client.DB().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := m.versions.Table()
+	var locked bool
+	if err := conn.QueryRowContext(ctx, lockSQL, name).Scan(&locked); err != nil || !locked {
+		conn.Close()
+		if err == nil {
+			err = errors.New("lock not granted")
+		}
+		return nil, fmt.Errorf("migrate: lock %s: %w", name, err)
+	}
+
+	return func() {
+		if _, err := conn.ExecContext(context.Background(), unlockSQL, name); err != nil {
+			// discard the connection, which releases its locks when closed
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, nil
+}
+
+// transactionalDDL returns false for MySQL, which commits implicitly after
+// schema changes.
+func transactionalDDL(d schemable.Dialect) bool {
+	return d != schemable.MySQL
+}
+
+// Exec runs the statement with the client, logging it to the client's
+// QueryLogger like the queries of a Schemer or Recorder.
+func Exec(ctx context.Context, c schemable.Client, q string, args ...any) error {
+	start := time.Now()
+	res, err := c.Exec(ctx, q, args...)
+
+	_, inTx := c.(*schemable.TxnClient)
+	e := &schemable.QueryEvent{SQL: q, Args: args, Duration: time.Since(start), Err: err, InTx: inTx}
+	if err == nil {
+		e.RowsAffected, _ = res.RowsAffected()
+	}
+	if l, ok := c.(schemable.QueryEventLogger); ok {
+		l.LogQueryEvent(ctx, e)
+	} else {
+		c.LogQuery(schemable.WithDBDuration(ctx, start), q, args)
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+package schemable
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ListColumns returns rows of type T embedded in Recorders like ListWhere,
+// selecting only the primary keys and the given columns. The other columns
+// are unloaded, so a later Update only writes them if their fields are set.
+// The context must have a client embedded with WithClient().
+func (s *Schemer[T]) ListColumns(ctx context.Context, cols []string, fn WhereFunc) ([]*Recorder[T], error) {
+	selected, err := s.columnSet(cols, true)
+	if err != nil {
+		return nil, err
+	}
+
+	q, err := startQuery(ctx, "ListColumns", OpSelect, s.table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.rows(fn(q.Builder().Select(s.selectColumns(selected)...).From(s.table)))
+	if err != nil || rows == nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	recs := make([]*Recorder[T], 0)
+	var rec *Recorder[T]
+	for rows.Next() {
+		rec = s.Record(nil)
+		err = rows.Scan(rec.columnRefs(selected)...)
+		if err != nil {
+			q.event.RowsReturned = int64(len(recs))
+			return recs, q.done(err)
+		}
+		rec.setLoaded(selected)
+		recs = append(recs, rec)
+	}
+	err = rows.Err()
+	q.event.RowsReturned = int64(len(recs))
+	return recs, q.done(err)
+}
+
+// LoadColumns reloads only the given columns of the Recorder Target from the
+// database, by its primary keys. Columns that were never loaded stay unloaded,
+// so a later Update only writes them if their fields are set.
+func (r *Recorder[T]) LoadColumns(ctx context.Context, cols ...string) error {
+	selected, err := r.Schemer.columnSet(cols, false)
+	if err != nil {
+		return err
+	}
+
+	q, err := startQuery(ctx, "LoadColumns", OpSelect, r.Schemer.table)
+	if err != nil {
+		return err
+	}
+
+	b := q.Builder().Select(r.Schemer.selectColumns(selected)...).From(r.Schemer.table).Where(r.WhereIDs())
+	err = q.scanRow(b, r.columnRefs(selected)...)
+	if err == nil {
+		r.setLoaded(selected)
+	}
+	return err
+}
+
+// Loaded returns false if the column has not been loaded from the database
+// since a ListColumns or LoadColumns call, so the field's value is unknown.
+func (r *Recorder[T]) Loaded(column string) bool {
+	return !r.unloaded[column]
+}
+
+// columnSet returns the set of the given columns, and the primary keys if
+// withKeys is true. It returns an error for unknown columns.
+func (s *Schemer[T]) columnSet(cols []string, withKeys bool) (map[string]bool, error) {
+	set := make(map[string]bool, len(cols)+len(s.keys))
+	if withKeys {
+		for _, k := range s.keys {
+			set[k.column] = true
+		}
+	}
+
+	for _, col := range cols {
+		found := false
+		for _, f := range s.fields {
+			if f.column == col {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("schemable: %s has no column %q", s.table, col)
+		}
+		set[col] = true
+	}
+	return set, nil
+}
+
+// selectColumns returns the table qualified columns in the set, in field
+// order.
+func (s *Schemer[T]) selectColumns(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for _, f := range s.fields {
+		if set[f.column] {
+			names = append(names, f.selectcolumn)
+		}
+	}
+	return names
+}
+
+// columnRefs returns pointers to the fields of the columns in the set, in
+// field order.
+func (r *Recorder[T]) columnRefs(set map[string]bool) []any {
+	var all []any
+	if r.Schemer.accessors {
+		all = any(r.Target).(Accessors).SchemableRefs()
+	}
+
+	refs := make([]any, 0, len(set))
+	rt := reflect.Indirect(reflect.ValueOf(r.Target))
+	for i, f := range r.Schemer.fields {
+		switch {
+		case !set[f.column]:
+		case all != nil:
+			refs = append(refs, all[i])
+		default:
+			refs = append(refs, rt.FieldByName(f.name).Addr().Interface())
+		}
+	}
+	return refs
+}
+
+// setLoaded records the values of the loaded columns for dirty tracking. On a
+// Recorder without recorded values, every other column is unloaded, and
+// recorded with its current value, so that Update skips it unless it changes.
+func (r *Recorder[T]) setLoaded(set map[string]bool) {
+	values := r.Values()
+	if r.values == nil {
+		r.values = values
+		r.unloaded = make(map[string]bool)
+		for _, f := range r.Schemer.fields {
+			if !f.isKey && !set[f.column] {
+				r.unloaded[f.column] = true
+			}
+		}
+		return
+	}
+
+	for col := range set {
+		if val, ok := values[col]; ok {
+			r.values[col] = val
+		}
+		delete(r.unloaded, col)
+	}
+}
+
+// sameValue compares column values, including ones like []byte that do not
+// support ==.
+func sameValue(a, b any) bool {
+	if t := reflect.TypeOf(a); t != nil && !t.Comparable() {
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
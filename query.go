@@ -0,0 +1,201 @@
+package schemable
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Op is the kind of SQL statement that a Schemer or Recorder runs.
+type Op string
+
+const (
+	OpSelect Op = "select"
+	OpInsert Op = "insert"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+// QueryEvent describes a query run by a Schemer or Recorder, including failed
+// ones.
+type QueryEvent struct {
+	Op    Op
+	Table string
+	SQL   string
+	Args  []any
+	// Duration covers the query, and scanning any returned rows.
+	Duration time.Duration
+	Err      error
+	// RowsAffected is set for insert, update, and delete queries if the
+	// driver supports it.
+	RowsAffected int64
+	// RowsReturned is the number of rows scanned from a select query.
+	RowsReturned int64
+	// InTx is true if the query ran in a transaction.
+	InTx bool
+	// Attempt is the try of the query by a RetryPolicy, starting at 1. It is
+	// 0 if the query was not eligible for retries.
+	Attempt int
+	// CacheErr is an error from the Schemer's Cache that did not fail the
+	// query, like a failed invalidation after a write.
+	CacheErr error
+}
+
+// QueryEventLogger is a wrapper for a type that logs QueryEvents.
+type QueryEventLogger interface {
+	LogQueryEvent(ctx context.Context, e *QueryEvent)
+}
+
+// query runs a single statement for a Schemer or Recorder method, and logs it
+// as a QueryEvent to the client from the context.
+type query struct {
+	ctx    context.Context
+	client Client
+	method string
+	span   Span
+	event  QueryEvent
+	start  time.Time
+	// invalidate runs after a successful exec, before the query is logged
+	invalidate func(ctx context.Context) error
+	// timeout is the client's read timeout, released once the query is done
+	timeout deadline
+}
+
+// startQuery returns a query for the client in the given context, starting a
+// span for the given method if the client has a Tracer.
+func startQuery(ctx context.Context, method string, op Op, table string) (*query, error) {
+	c := ClientFrom(ctx)
+	if c == nil {
+		return nil, ErrNoClient
+	}
+
+	_, inTx := c.(*TxnClient)
+	q := &query{
+		client: c,
+		method: method,
+		event:  QueryEvent{Op: op, Table: table, InTx: inTx},
+	}
+	q.ctx, q.span = startSpan(ctx, c, method, op, table)
+	q.ctx = context.WithValue(q.ctx, eventKey, &q.event)
+	q.ctx = context.WithValue(q.ctx, releaseKey, &q.timeout)
+	return q, nil
+}
+
+// Builder is the squirrel query builder of the query's client.
+func (q *query) Builder() *sq.StatementBuilderType {
+	return q.client.Builder()
+}
+
+// exec runs the given insert, update, or delete statement. If it succeeds,
+// the query's invalidate func runs, and any error it returns is logged as the
+// event's CacheErr instead of failing the query.
+func (q *query) exec(b sq.Sqlizer) (sql.Result, error) {
+	if err := q.build(b); err != nil {
+		return nil, err
+	}
+
+	res, err := q.client.Exec(q.ctx, q.event.SQL, q.event.Args...)
+	if err == nil {
+		if res != nil {
+			q.event.RowsAffected, _ = res.RowsAffected()
+		}
+		if q.invalidate != nil {
+			q.event.CacheErr = q.invalidate(q.ctx)
+		}
+	}
+	q.done(err)
+	return res, err
+}
+
+// scanRow runs the given select statement, scanning the first row into dest.
+func (q *query) scanRow(b sq.Sqlizer, dest ...any) error {
+	if err := q.build(b); err != nil {
+		return err
+	}
+
+	err := q.client.QueryRow(q.ctx, q.event.SQL, q.event.Args...).Scan(dest...)
+	if err == nil {
+		q.event.RowsReturned = 1
+	}
+	return q.done(err)
+}
+
+// rows runs the given select statement. Callers scan the rows, then call
+// done with the count of scanned rows, which releases the read timeout.
+func (q *query) rows(b sq.Sqlizer) (*sql.Rows, error) {
+	if err := q.build(b); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.client.Query(q.ctx, q.event.SQL, q.event.Args...)
+	if err != nil {
+		q.done(err)
+	}
+	return rows, err
+}
+
+// build generates the SQL and args for the given statement, and starts the
+// query's timer. The SQL includes a sqlcommenter comment if the client has
+// them enabled.
+func (q *query) build(b sq.Sqlizer) error {
+	q.start = time.Now()
+	var err error
+	q.event.SQL, q.event.Args, err = b.ToSql()
+	if err != nil {
+		q.done(err)
+		return err
+	}
+	// marks the statement as cacheable by a DBClient's statement cache
+	q.ctx = context.WithValue(q.ctx, stmtKey, q.event.SQL)
+
+	if qc, ok := q.client.(interface{ commenter() queryComments }); ok {
+		q.event.SQL = qc.commenter().comment(q.ctx, q.event.SQL, q.event.Table, q.method)
+	}
+	return nil
+}
+
+// done releases the query's read timeout, logs the query with the given error,
+// and ends its span. It returns the error, as a *TimeoutError if the read
+// timeout expired while the rows were scanned.
+func (q *query) done(err error) error {
+	err = q.timeout.end(err)
+	q.event.Duration = time.Since(q.start)
+	q.event.Err = err
+	logEvent(q.ctx, q.client, &q.event)
+
+	if q.span == nil {
+		return err
+	}
+	attrs := []Attribute{{Key: AttrStatement, Value: q.event.SQL}}
+	switch q.event.Op {
+	case OpSelect:
+		attrs = append(attrs, Attribute{Key: AttrRowsReturned, Value: q.event.RowsReturned})
+	default:
+		attrs = append(attrs, Attribute{Key: AttrRowsAffected, Value: q.event.RowsAffected})
+	}
+	q.span.SetAttributes(attrs...)
+	q.span.End(err)
+	return err
+}
+
+// logEvent sends the event to the client's QueryEventLogger, or its
+// QueryLogger if it has none.
+func logEvent(ctx context.Context, c Client, e *QueryEvent) {
+	ctx = context.WithValue(ctx, dbDurKey, e.Duration)
+	if l, ok := c.(QueryEventLogger); ok {
+		l.LogQueryEvent(ctx, e)
+		return
+	}
+	c.LogQuery(ctx, e.SQL, e.Args)
+}
+
+// eventLogger adapts a QueryLogger to a QueryEventLogger.
+type eventLogger struct {
+	QueryLogger
+}
+
+func (l eventLogger) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+	l.LogQuery(context.WithValue(ctx, dbDurKey, e.Duration), e.SQL, e.Args)
+}
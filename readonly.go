@@ -0,0 +1,116 @@
+package schemable
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable/internal/sqlstub"
+)
+
+// ErrReadOnly is returned for writes through a read-only client or
+// transaction, without sending them to the database.
+var ErrReadOnly = errors.New("schemable: write in read-only client")
+
+// ReadOnlyClient is a Client view of a DBClient that rejects writes with
+// ErrReadOnly. Transactions started with Begin are read-only too.
+type ReadOnlyClient struct {
+	client *DBClient
+}
+
+// ReadOnly returns a read-only view of this client. It shares the client's
+// connection and settings, including any set after this call.
+func (c *DBClient) ReadOnly() *ReadOnlyClient {
+	return &ReadOnlyClient{client: c}
+}
+
+// Client returns the underlying DBClient, which allows writes.
+func (c *ReadOnlyClient) Client() *DBClient {
+	return c.client
+}
+
+// Begin starts a transaction with the ReadOnly option, keeping the isolation
+// level of the given options. Writes in the transaction return ErrReadOnly.
+func (c *ReadOnlyClient) Begin(ctx context.Context, opts *sql.TxOptions) (*TxnClient, error) {
+	ro := sql.TxOptions{ReadOnly: true}
+	if opts != nil {
+		ro.Isolation = opts.Isolation
+	}
+	return c.client.Begin(ctx, &ro)
+}
+
+// Exec returns ErrReadOnly.
+func (c *ReadOnlyClient) Exec(ctx context.Context, q string, args ...any) (sql.Result, error) {
+	return nil, ErrReadOnly
+}
+
+// Query executes a query that returns rows, typically a SELECT. Statements that
+// write, like an INSERT with a RETURNING clause, return ErrReadOnly.
+func (c *ReadOnlyClient) Query(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
+	if isWrite(Statement{Kind: QueryStatement, SQL: q}) {
+		return nil, ErrReadOnly
+	}
+	return c.client.Query(ctx, q, args...)
+}
+
+// QueryRow executes a query that is expected to return at most one row. See
+// DBClient#QueryRow. Statements that write return a *sql.Row with ErrReadOnly.
+func (c *ReadOnlyClient) QueryRow(ctx context.Context, q string, args ...any) *sql.Row {
+	if isWrite(Statement{Kind: QueryRowStatement, SQL: q}) {
+		return sqlstub.ErrRow(ErrReadOnly)
+	}
+	return c.client.QueryRow(ctx, q, args...)
+}
+
+// Builder is the squirrel query builder for the underlying DBClient.
+func (c *ReadOnlyClient) Builder() *sq.StatementBuilderType {
+	return c.client.Builder()
+}
+
+// Dialect returns the SQL dialect of the database.
+func (c *ReadOnlyClient) Dialect() Dialect {
+	return c.client.Dialect()
+}
+
+// LogQuery logs the given query info to the underlying DBClient's logger.
+func (c *ReadOnlyClient) LogQuery(ctx context.Context, q string, args []any) {
+	c.client.LogQuery(ctx, q, args)
+}
+
+// LogQueryEvent logs the given QueryEvent to the underlying DBClient's logger.
+func (c *ReadOnlyClient) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+	c.client.LogQueryEvent(ctx, e)
+}
+
+// Tracer returns the underlying DBClient's Tracer.
+func (c *ReadOnlyClient) Tracer() Tracer {
+	return c.client.Tracer()
+}
+
+func (c *ReadOnlyClient) commenter() queryComments {
+	return c.client.commenter()
+}
+
+// readOnlyHandler rejects writes with ErrReadOnly before calling next.
+func readOnlyHandler(next Handler) Handler {
+	return func(ctx context.Context, stmt Statement) Response {
+		if isWrite(stmt) {
+			return Response{Err: ErrReadOnly}
+		}
+		return next(ctx, stmt)
+	}
+}
+
+// isWrite returns true for Exec statements, and for queries that insert,
+// update, or delete rows.
+func isWrite(stmt Statement) bool {
+	if stmt.Kind == ExecStatement {
+		return true
+	}
+	switch statementOp(stmt.SQL) {
+	case OpInsert, OpUpdate, OpDelete:
+		return true
+	}
+	return false
+}
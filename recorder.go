@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"reflect"
-	"time"
 )
 
 // Recorder records changes to Target of type T using its Schemer.
@@ -12,29 +11,37 @@ type Recorder[T any] struct {
 	Schemer *Schemer[T]
 	Target *T
 	values map[string]any
+	// columns not loaded by ListColumns or LoadColumns
+	unloaded map[string]bool
 }
 
 // Load reloads the Recorder Target's columns (except primary keys) from the
-// database.
+// database. With an IdentityMap in the context, it copies the Target already
+// cached for its row into the Recorder Target instead, without a query, and
+// then sets the Recorder Target to the cached one. Otherwise, a Schemer
+// with a Cache loads the columns from it if the row is cached.
 func (r *Recorder[T]) Load(ctx context.Context) error {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return ErrNoClient
+	if r.cached(ctx) {
+		return nil
+	}
+	if r.loadCache(ctx) {
+		r.setValues()
+		r.remember(ctx)
+		return nil
 	}
+	fill := r.startFill(ctx)
 
-	q := c.Builder().Select(r.Schemer.Columns(false)...).From(r.Schemer.table).Where(r.WhereIDs())
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "Load", OpSelect, r.Schemer.table)
 	if err != nil {
 		return err
 	}
 
-
-	start := time.Now()
-	refs := r.fieldRefs(false)
-	err = c.QueryRow(ctx, qu, args...).Scan(refs...)
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
+	b := q.Builder().Select(r.Schemer.Columns(false)...).From(r.Schemer.table).Where(r.WhereIDs())
+	err = q.scanRow(b, r.fieldRefs(false)...)
 	if err == nil {
 		r.setValues()
+		r.storeCache(ctx, fill)
+		r.remember(ctx)
 	}
 	return err
 }
@@ -42,21 +49,13 @@ func (r *Recorder[T]) Load(ctx context.Context) error {
 // LoadWhere loads a single Recorder Target using the given predicate args for
 // a Where clause on the squirrel query builder.
 func (r *Recorder[T]) LoadWhere(ctx context.Context, pred any, args ...any) error {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return ErrNoClient
-	}
-
-	q := c.Builder().Select(r.Schemer.Columns(true)...).From(r.Schemer.table).Where(pred, args...)
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "LoadWhere", OpSelect, r.Schemer.table)
 	if err != nil {
 		return err
 	}
 
-	start := time.Now()
-	refs := r.fieldRefs(true)
-	err = c.QueryRow(ctx, qu, args...).Scan(refs...)
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
+	b := q.Builder().Select(r.Schemer.Columns(true)...).From(r.Schemer.table).Where(pred, args...)
+	err = q.scanRow(b, r.fieldRefs(true)...)
 	if err == nil {
 		r.setValues()
 	}
@@ -71,21 +70,14 @@ func (r *Recorder[T]) Exists(ctx context.Context) (bool, error) {
 
 // Insert uses the Recorder's Schemer to insert the Target into the database.
 func (r *Recorder[T]) Insert(ctx context.Context) error {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return ErrNoClient
-	}
-
-	cols, vals := r.colValLists(true, false)
-	q := c.Builder().Insert(r.Schemer.table).Columns(cols...).Values(vals...)
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "Insert", OpInsert, r.Schemer.table)
 	if err != nil {
 		return err
 	}
 
-	start := time.Now()
-	res, err := c.Exec(ctx, qu, args...)
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
+	cols, vals := r.colValLists(true, false)
+	b := q.Builder().Insert(r.Schemer.table).Columns(cols...).Values(vals...)
+	res, err := q.exec(b)
 	if err != nil {
 		return err
 	}
@@ -96,6 +88,9 @@ func (r *Recorder[T]) Insert(ctx context.Context) error {
 			continue
 		}
 
+		if res == nil {
+			return fmt.Errorf("could not get last insert ID: no sql.Result")
+		}
 		id, err := res.LastInsertId()
 		if err != nil {
 			return fmt.Errorf("could not get last insert ID. did you set the db driver? %s", err)
@@ -108,6 +103,12 @@ func (r *Recorder[T]) Insert(ctx context.Context) error {
 		field.SetInt(id)
 	}
 	r.setValues()
+	cols, vals = r.colValLists(true, true)
+	inserted := make(map[string]any, len(cols))
+	for i, col := range cols {
+		inserted[col] = vals[i]
+	}
+	r.Schemer.publish(ctx, Inserted, r.Target, inserted)
 	return nil
 }
 
@@ -119,22 +120,21 @@ func (r *Recorder[T]) Update(ctx context.Context) error {
 		return nil
 	}
 
-	c := ClientFrom(ctx)
-	if c == nil {
-		return ErrNoClient
-	}
-
-	q := c.Builder().Update(r.Schemer.table).SetMap(updates).Where(r.WhereIDs())
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "Update", OpUpdate, r.Schemer.table)
 	if err != nil {
 		return err
 	}
 
-	start := time.Now()
-	_, err = c.Exec(ctx, qu, args...)
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
-	if err == nil {
-		r.setValues()
+	b := q.Builder().Update(r.Schemer.table).SetMap(updates).Where(r.WhereIDs())
+	q.invalidate = r.invalidateCache
+	if _, err = q.exec(b); err == nil {
+		// columns that were not updated stay unloaded
+		for col := range updates {
+			delete(r.unloaded, col)
+		}
+		r.values = r.Values()
+		r.forget(ctx)
+		r.Schemer.publish(ctx, Updated, r.Target, updates)
 	}
 	return err
 }
@@ -142,20 +142,17 @@ func (r *Recorder[T]) Update(ctx context.Context) error {
 // Delete removes this Recorder's Target from its Schemer's table in the
 // database.
 func (r *Recorder[T]) Delete(ctx context.Context) error {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return ErrNoClient
-	}
-
-	q := c.Builder().Delete(r.Schemer.table).Where(r.WhereIDs())
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "Delete", OpDelete, r.Schemer.table)
 	if err != nil {
 		return err
 	}
 
-	start := time.Now()
-	_, err = c.Exec(ctx, qu, args...)
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
+	q.invalidate = r.invalidateCache
+	_, err = q.exec(q.Builder().Delete(r.Schemer.table).Where(r.WhereIDs()))
+	if err == nil {
+		r.forget(ctx)
+		r.Schemer.publish(ctx, Deleted, r.Target, nil)
+	}
 	return err
 }
 
@@ -169,7 +166,7 @@ func (r *Recorder[T]) UpdatedValues() map[string]any {
 
 	for col, val := range values {
 		orig, ok := r.values[col]
-		if ok && orig == val {
+		if ok && sameValue(orig, val) {
 			delete(values, col)
 		}
 	}
@@ -191,6 +188,16 @@ func (r *Recorder[T]) Values() map[string]any {
 func (r *Recorder[T]) WhereIDs() map[string]any {
 	clause := make(map[string]any, len(r.Schemer.keys))
 
+	if r.Schemer.accessors {
+		vals := any(r.Target).(Accessors).SchemableValues()
+		for i, f := range r.Schemer.fields {
+			if f.isKey {
+				clause[f.selectcolumn] = vals[i]
+			}
+		}
+		return clause
+	}
+
 	rt := reflect.Indirect(reflect.ValueOf(r.Target))
 
 	for _, f := range r.Schemer.keys {
@@ -201,6 +208,21 @@ func (r *Recorder[T]) WhereIDs() map[string]any {
 }
 
 func (r *Recorder[T]) fieldRefs(withKeys bool) []any {
+	if r.Schemer.accessors {
+		refs := any(r.Target).(Accessors).SchemableRefs()
+		if withKeys {
+			return refs
+		}
+
+		nokeys := make([]any, 0, len(refs))
+		for i, field := range r.Schemer.fields {
+			if !field.isKey {
+				nokeys = append(nokeys, refs[i])
+			}
+		}
+		return nokeys
+	}
+
 	refs := make([]any, 0, len(r.Schemer.fields))
 
 	ar := reflect.Indirect(reflect.ValueOf(r.Target))
@@ -219,6 +241,7 @@ func (r *Recorder[T]) fieldRefs(withKeys bool) []any {
 
 func (r *Recorder[T]) setValues() {
 	r.values = r.Values()
+	r.unloaded = nil
 }
 
 // colValLists returns 2 lists, the column names and values.
@@ -226,14 +249,22 @@ func (r *Recorder[T]) setValues() {
 // will not be included in those lists. Also, if withAutos is false, the returned
 // lists will not include fields designated as auto-increment.
 func (r *Recorder[T]) colValLists(withKeys, withAutos bool) (columns []string, values []any) {
+	var vals []any
+	if r.Schemer.accessors {
+		vals = any(r.Target).(Accessors).SchemableValues()
+	}
 	rt := reflect.Indirect(reflect.ValueOf(r.Target))
 
-	for _, field := range r.Schemer.fields {
+	for i, field := range r.Schemer.fields {
 		switch {
 		case !withKeys && field.isKey:
 			continue
 		case !withAutos && field.isAuto:
 			continue
+		case vals != nil:
+			values = append(values, vals[i])
+			columns = append(columns, field.column)
+			continue
 		}
 
 		// Get the value of the field we are going to store.
@@ -0,0 +1,111 @@
+package schemable
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy retries queries of a DBClient that fail with a transient error.
+// It applies to reads outside of transactions, and to writes in contexts from
+// WithIdempotent.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of tries of a query, including the
+	// first. Retries are disabled if it is less than 2.
+	MaxAttempts int
+
+	// BaseDelay is the wait before the first retry, doubling for each retry
+	// after that.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the wait between retries if set.
+	MaxDelay time.Duration
+
+	// Jitter is the fraction, from 0 to 1, of each wait that is randomized,
+	// so that clients do not retry in lockstep.
+	Jitter float64
+
+	// Transient returns true if a query that failed with the given error
+	// should be retried. Defaults to the IsTransient method of the client's
+	// Dialect.
+	Transient func(err error) bool
+}
+
+// WithIdempotent returns a context that allows a DBClient's RetryPolicy to
+// retry writes, because running them more than once has no extra effect.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey, true)
+}
+
+// delay returns the wait before the given retry, starting at 1.
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < retry && (p.MaxDelay <= 0 || d < p.MaxDelay); i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// retries returns true if the given statement can be retried.
+func (p RetryPolicy) retries(ctx context.Context, stmt Statement) bool {
+	if p.MaxAttempts < 2 {
+		return false
+	}
+	if idempotent, _ := ctx.Value(idempotentKey).(bool); idempotent {
+		return true
+	}
+	return stmt.Kind != ExecStatement && statementOp(stmt.SQL) == OpSelect
+}
+
+// runWithRetry runs the statement, retrying transient errors according to the
+// client's RetryPolicy. Failed attempts that are retried are logged as
+// QueryEvents.
+func (c *DBClient) runWithRetry(ctx context.Context, stmt Statement) Response {
+	p := c.retry
+	if !p.retries(ctx, stmt) {
+		return c.run(ctx, c.handler, stmt)
+	}
+
+	transient := p.Transient
+	if transient == nil {
+		transient = c.dialect.IsTransient
+	}
+
+	// the event of a Schemer or Recorder query in progress
+	parent, _ := ctx.Value(eventKey).(*QueryEvent)
+
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		res := c.run(ctx, c.handler, stmt)
+		if parent != nil {
+			parent.Attempt = attempt
+		}
+
+		if res.Err == nil || attempt >= p.MaxAttempts || !transient(res.Err) || ctx.Err() != nil {
+			return res
+		}
+
+		e := &QueryEvent{Op: statementOp(stmt.SQL), SQL: stmt.SQL, Args: stmt.Args}
+		if parent != nil {
+			*e = *parent
+		}
+		e.Duration = time.Since(start)
+		e.Err = res.Err
+		e.Attempt = attempt
+		logEvent(ctx, c, e)
+
+		t := time.NewTimer(p.delay(attempt))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return res
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package schemable
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// ReplicaPolicy decides which replica a RoutingClient sends a read to.
+type ReplicaPolicy int
+
+const (
+	// RoundRobin cycles through the replicas in order.
+	RoundRobin ReplicaPolicy = iota
+	// LeastLatency picks the replica with the lowest average query latency.
+	LeastLatency
+)
+
+// RoutingClient is a Client that sends Query and QueryRow to a pool of replica
+// DBClients, and Exec to the primary DBClient. Transactions started with Begin
+// always run on the primary. A replica that fails a read is skipped for a few
+// seconds, and reads go to the primary if every replica is down.
+type RoutingClient struct {
+	primary  *DBClient
+	replicas []*replica
+	policy   ReplicaPolicy
+	next     uint64
+}
+
+// replica tracks a moving average of the query latency of a replica DBClient,
+// and when it last failed.
+type replica struct {
+	client    *DBClient
+	mu        sync.Mutex
+	latency   time.Duration
+	downUntil time.Time
+}
+
+// replicaCooldown is how long a replica is skipped after a failed read.
+const replicaCooldown = 10 * time.Second
+
+// NewRouting returns a RoutingClient that writes to the primary and reads from
+// the given replicas using the given policy. Reads go to the primary if no
+// replicas are given.
+func NewRouting(primary *DBClient, policy ReplicaPolicy, replicas ...*DBClient) *RoutingClient {
+	rc := &RoutingClient{
+		primary:  primary,
+		replicas: make([]*replica, len(replicas)),
+		policy:   policy,
+	}
+	for i, c := range replicas {
+		rc.replicas[i] = &replica{client: c}
+	}
+	return rc
+}
+
+// Primary returns the DBClient that receives writes.
+func (c *RoutingClient) Primary() *DBClient {
+	return c.primary
+}
+
+// Reader returns the DBClient that serves reads for the given context.
+func (c *RoutingClient) Reader(ctx context.Context) *DBClient {
+	if r := c.pick(ctx); r != nil {
+		return r.client
+	}
+	return c.primary
+}
+
+// Begin starts a transaction on the primary. See database/sql#DB.BeginTx.
+// Reads in a context from WithStickyPrimary go to the primary once a
+// transaction that is not read only commits.
+func (c *RoutingClient) Begin(ctx context.Context, opts *sql.TxOptions) (*TxnClient, error) {
+	tc, err := c.primary.Begin(ctx, opts)
+	if tc == nil {
+		return tc, err
+	}
+	tc.parent = c
+	if sticky, ok := ctx.Value(primaryKey).(*int32); ok && !tc.readOnly {
+		tc.OnCommit(func() { atomic.StoreInt32(sticky, 1) })
+	}
+	return tc, err
+}
+
+// Exec executes a query on the primary without returning any rows. Reads in a
+// context from WithStickyPrimary go to the primary after this.
+func (c *RoutingClient) Exec(ctx context.Context, q string, args ...any) (sql.Result, error) {
+	if sticky, ok := ctx.Value(primaryKey).(*int32); ok {
+		atomic.StoreInt32(sticky, 1)
+	}
+	return c.primary.Exec(ctx, q, args...)
+}
+
+// Query executes a query that returns rows on a replica, typically a SELECT.
+func (c *RoutingClient) Query(ctx context.Context, q string, args ...any) (*sql.Rows, error) {
+	r := c.pick(ctx)
+	if r == nil {
+		return c.primary.Query(ctx, q, args...)
+	}
+
+	start := time.Now()
+	rows, err := r.client.Query(ctx, q, args...)
+	r.record(ctx, time.Since(start), err)
+	return rows, err
+}
+
+// QueryRow executes a query that is expected to return at most one row on a
+// replica. See DBClient#QueryRow.
+func (c *RoutingClient) QueryRow(ctx context.Context, q string, args ...any) *sql.Row {
+	r := c.pick(ctx)
+	if r == nil {
+		return c.primary.QueryRow(ctx, q, args...)
+	}
+
+	start := time.Now()
+	row := r.client.QueryRow(ctx, q, args...)
+	r.record(ctx, time.Since(start), row.Err())
+	return row
+}
+
+// Builder is the squirrel query builder for the primary.
+func (c *RoutingClient) Builder() *sq.StatementBuilderType {
+	return c.primary.Builder()
+}
+
+// LogQuery logs the given query info to the primary's logger.
+func (c *RoutingClient) LogQuery(ctx context.Context, q string, args []any) {
+	c.primary.LogQuery(ctx, q, args)
+}
+
+// LogQueryEvent logs the given QueryEvent to the primary's logger.
+func (c *RoutingClient) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+	c.primary.LogQueryEvent(ctx, e)
+}
+
+// Tracer returns the primary's Tracer.
+func (c *RoutingClient) Tracer() Tracer {
+	return c.primary.Tracer()
+}
+
+func (c *RoutingClient) commenter() queryComments {
+	return c.primary.commenter()
+}
+
+// pick returns the replica for a read, or nil if the read should go to the
+// primary.
+func (c *RoutingClient) pick(ctx context.Context) *replica {
+	if len(c.replicas) == 0 {
+		return nil
+	}
+
+	switch v := ctx.Value(primaryKey).(type) {
+	case bool:
+		if v {
+			return nil
+		}
+	case *int32:
+		if atomic.LoadInt32(v) == 1 {
+			return nil
+		}
+	}
+
+	now := time.Now()
+	if c.policy == LeastLatency {
+		var best *replica
+		var bestLatency time.Duration
+		for _, r := range c.replicas {
+			l, up := r.average(now)
+			if up && (best == nil || l < bestLatency) {
+				best, bestLatency = r, l
+			}
+		}
+		return best
+	}
+
+	n := uint64(len(c.replicas))
+	for tries := uint64(0); tries < n; tries++ {
+		i := atomic.AddUint64(&c.next, 1) - 1
+		if r := c.replicas[i%n]; r.up(now) {
+			return r
+		}
+	}
+	return nil
+}
+
+// average returns the replica's latency, and whether it is up.
+func (r *replica) average(now time.Time) (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latency, !now.Before(r.downUntil)
+}
+
+func (r *replica) up(now time.Time) bool {
+	_, up := r.average(now)
+	return up
+}
+
+// record observes the duration of a successful read, or skips the replica for
+// replicaCooldown after a failed one. Reads that fail because the caller's
+// context is done do not count against the replica.
+func (r *replica) record(ctx context.Context, d time.Duration, err error) {
+	switch {
+	case err == nil:
+		r.observe(d)
+	case ctx.Err() == nil:
+		r.mu.Lock()
+		r.downUntil = time.Now().Add(replicaCooldown)
+		r.mu.Unlock()
+	}
+}
+
+// observe folds the given query duration into the moving average, weighing
+// the newest sample at 1/4.
+func (r *replica) observe(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.latency == 0 {
+		r.latency = d
+		return
+	}
+	r.latency += (d - r.latency) / 4
+}
+
+// WithPrimary returns a context that sends reads through a RoutingClient to
+// its primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, true)
+}
+
+// WithStickyPrimary returns a context that sends reads through a RoutingClient
+// to its primary once a write has been executed with it, or a transaction begun
+// with it has committed, so that callers read their own writes despite replica
+// lag.
+func WithStickyPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey, new(int32))
+}
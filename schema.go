@@ -0,0 +1,348 @@
+package schemable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+// TableSchema describes the columns of a table, as mapped by a Schemer or as
+// introspected from the database.
+type TableSchema struct {
+	Table   string
+	Columns []ColumnSchema
+}
+
+// ColumnSchema describes a column of a table.
+type ColumnSchema struct {
+	Name string
+	// Type is the column type reported by the database. It is empty for a
+	// Schemer's columns.
+	Type          string
+	Nullable      bool
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+// Column returns the column with the given name, or nil.
+func (s *TableSchema) Column(name string) *ColumnSchema {
+	for i := range s.Columns {
+		if s.Columns[i].Name == name {
+			return &s.Columns[i]
+		}
+	}
+	return nil
+}
+
+// primaryKey returns the sorted names of the primary key columns.
+func (s *TableSchema) primaryKey() []string {
+	var keys []string
+	for _, c := range s.Columns {
+		if c.PrimaryKey {
+			keys = append(keys, c.Name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TableSchemer is a type that maps a table, like a *Schemer.
+type TableSchemer interface {
+	TableSchema() *TableSchema
+}
+
+// TableSchema returns the table and columns that the Schemer's type T maps.
+// Pointer and sql.Null* fields are Nullable.
+func (s *Schemer[T]) TableSchema() *TableSchema {
+	ts := &TableSchema{Table: s.table, Columns: make([]ColumnSchema, len(s.fields))}
+	for i, f := range s.fields {
+		ts.Columns[i] = ColumnSchema{
+			Name:          f.column,
+			Nullable:      f.isOptional && !f.isKey,
+			PrimaryKey:    f.isKey,
+			AutoIncrement: f.isAuto,
+		}
+	}
+	return ts
+}
+
+// ErrNoTable is returned by Introspect for a table that does not exist.
+var ErrNoTable = errors.New("schemable: table does not exist")
+
+// Introspect returns the columns of the given table in the database of the
+// client in the context, using information_schema, or PRAGMA table_info for
+// SQLite.
+func Introspect(ctx context.Context, table string) (*TableSchema, error) {
+	d, err := clientDialect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmt string
+	switch d {
+	case SQLite:
+		stmt = `SELECT name, type, "notnull" = 0, pk > 0, 0 FROM pragma_table_info(?) ORDER BY cid`
+	case MySQL:
+		stmt = "SELECT column_name, column_type, is_nullable = 'YES', column_key = 'PRI', " +
+			"extra LIKE '%auto_increment%' " +
+			"FROM information_schema.columns " +
+			"WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position"
+	case Postgres:
+		stmt = "SELECT c.column_name, c.data_type, c.is_nullable = 'YES', EXISTS (" +
+			"SELECT 1 FROM information_schema.table_constraints tc " +
+			"JOIN information_schema.key_column_usage k ON k.constraint_name = tc.constraint_name " +
+			"AND k.table_schema = tc.table_schema AND k.table_name = tc.table_name " +
+			"WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema " +
+			"AND tc.table_name = c.table_name AND k.column_name = c.column_name), " +
+			"c.is_identity = 'YES' OR COALESCE(c.column_default, '') LIKE 'nextval(%' " +
+			"FROM information_schema.columns c " +
+			"WHERE c.table_schema = current_schema() AND c.table_name = $1 ORDER BY c.ordinal_position"
+	}
+
+	q, err := startQuery(ctx, "Introspect", OpSelect, table)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.rows(sq.Expr(stmt, table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts := &TableSchema{Table: table}
+	var keys []int
+	for rows.Next() {
+		var col ColumnSchema
+		if err = rows.Scan(&col.Name, &col.Type, &col.Nullable, &col.PrimaryKey, &col.AutoIncrement); err != nil {
+			break
+		}
+		if col.PrimaryKey && d == SQLite {
+			// SQLite allows NULL in primary keys that are not INTEGER
+			col.Nullable = false
+			keys = append(keys, len(ts.Columns))
+		}
+		ts.Columns = append(ts.Columns, col)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	q.event.RowsReturned = int64(len(ts.Columns))
+	err = q.done(err)
+
+	switch {
+	case err != nil:
+		return nil, err
+	case len(ts.Columns) == 0:
+		return nil, fmt.Errorf("%w: %s", ErrNoTable, table)
+	}
+
+	// a sole INTEGER PRIMARY KEY is an alias of the auto incrementing rowid
+	if len(keys) == 1 && strings.EqualFold(ts.Columns[keys[0]].Type, "INTEGER") {
+		ts.Columns[keys[0]].AutoIncrement = true
+	}
+	return ts, nil
+}
+
+// Tables returns the names of the tables in the database of the client in
+// the context, in alphabetical order.
+func Tables(ctx context.Context) ([]string, error) {
+	d, err := clientDialect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmt string
+	switch d {
+	case SQLite:
+		stmt = "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name"
+	case MySQL:
+		stmt = "SELECT table_name FROM information_schema.tables " +
+			"WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name"
+	case Postgres:
+		stmt = "SELECT table_name FROM information_schema.tables " +
+			"WHERE table_schema = current_schema() AND table_type = 'BASE TABLE' ORDER BY table_name"
+	}
+
+	q, err := startQuery(ctx, "Tables", OpSelect, "")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := q.rows(sq.Expr(stmt))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			break
+		}
+		tables = append(tables, name)
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	q.event.RowsReturned = int64(len(tables))
+	return tables, q.done(err)
+}
+
+// clientDialect returns the known Dialect of the client in the context.
+func clientDialect(ctx context.Context) (Dialect, error) {
+	c := ClientFrom(ctx)
+	if c == nil {
+		return UnknownDialect, ErrNoClient
+	}
+
+	var d Dialect
+	if dc, ok := c.(interface{ Dialect() Dialect }); ok {
+		d = dc.Dialect()
+	}
+	if d == UnknownDialect {
+		return d, fmt.Errorf("schemable: cannot introspect %s dialect", d)
+	}
+	return d, nil
+}
+
+// DriftKind is a difference between a Schemer and its table.
+type DriftKind int
+
+const (
+	MissingTable DriftKind = iota + 1
+	MissingColumn
+	ExtraColumn
+	NullabilityMismatch
+	PrimaryKeyMismatch
+)
+
+func (k DriftKind) String() string {
+	switch k {
+	case MissingTable:
+		return "missing table"
+	case MissingColumn:
+		return "missing column"
+	case ExtraColumn:
+		return "extra column"
+	case NullabilityMismatch:
+		return "nullability mismatch"
+	case PrimaryKeyMismatch:
+		return "primary key mismatch"
+	}
+	return "unknown drift"
+}
+
+// SchemaDrift is a difference between a Schemer and its table in the
+// database. Column is empty for table level drift.
+type SchemaDrift struct {
+	Kind   DriftKind
+	Table  string
+	Column string
+	Detail string
+}
+
+func (d SchemaDrift) String() string {
+	name := d.Table
+	if d.Column != "" {
+		name += "." + d.Column
+	}
+	if d.Detail == "" {
+		return name + ": " + d.Kind.String()
+	}
+	return name + ": " + d.Kind.String() + " (" + d.Detail + ")"
+}
+
+// SchemaError is returned by CheckSchema if the database does not match the
+// Schemers.
+type SchemaError struct {
+	Drifts []SchemaDrift
+}
+
+func (e *SchemaError) Error() string {
+	msgs := make([]string, len(e.Drifts))
+	for i, d := range e.Drifts {
+		msgs[i] = d.String()
+	}
+	return "schemable: schema drift: " + strings.Join(msgs, "; ")
+}
+
+// CheckSchema compares the tables of the given Schemers with the database of
+// the client in the context, returning a *SchemaError that lists missing
+// tables, missing or extra columns, nullability mismatches, and primary key
+// differences. Services can call it at startup to fail fast:
+//
+//	if err := schemable.CheckSchema(ctx, ComicTitles, Publishers); err != nil {
+//		log.Fatal(err)
+//	}
+func CheckSchema(ctx context.Context, schemers ...TableSchemer) error {
+	d, err := clientDialect(ctx)
+	if err != nil {
+		return err
+	}
+
+	var drifts []SchemaDrift
+	for _, s := range schemers {
+		want := s.TableSchema()
+		got, err := Introspect(ctx, want.Table)
+		if errors.Is(err, ErrNoTable) {
+			drifts = append(drifts, SchemaDrift{Kind: MissingTable, Table: want.Table})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		drifts = append(drifts, compareSchemas(d, want, got)...)
+	}
+	if len(drifts) > 0 {
+		return &SchemaError{Drifts: drifts}
+	}
+	return nil
+}
+
+func compareSchemas(d Dialect, want, got *TableSchema) []SchemaDrift {
+	var drifts []SchemaDrift
+	for _, wc := range want.Columns {
+		gc := got.Column(wc.Name)
+		switch {
+		case gc == nil:
+			drifts = append(drifts, SchemaDrift{Kind: MissingColumn, Table: want.Table, Column: wc.Name})
+		case wc.Nullable != gc.Nullable:
+			drifts = append(drifts, SchemaDrift{
+				Kind:   NullabilityMismatch,
+				Table:  want.Table,
+				Column: wc.Name,
+				Detail: fmt.Sprintf("field nullable: %t, column nullable: %t", wc.Nullable, gc.Nullable),
+			})
+		}
+	}
+	for _, gc := range got.Columns {
+		if want.Column(gc.Name) == nil {
+			drifts = append(drifts, SchemaDrift{Kind: ExtraColumn, Table: want.Table, Column: gc.Name})
+		}
+	}
+
+	wantKeys, gotKeys := want.primaryKey(), got.primaryKey()
+	if strings.Join(wantKeys, ",") != strings.Join(gotKeys, ",") && !(d == SQLite && sqliteAutoKey(want, gotKeys)) {
+		drifts = append(drifts, SchemaDrift{
+			Kind:   PrimaryKeyMismatch,
+			Table:  want.Table,
+			Detail: fmt.Sprintf("want (%s), got (%s)", strings.Join(wantKeys, ", "), strings.Join(gotKeys, ", ")),
+		})
+	}
+	return drifts
+}
+
+// sqliteAutoKey returns true if the table's primary key is only the auto
+// increment column of the Schemer's composite key, like the SQLite tables from
+// CreateTableSQL. Other dialects keep the composite key.
+func sqliteAutoKey(want *TableSchema, gotKeys []string) bool {
+	if len(gotKeys) != 1 {
+		return false
+	}
+	c := want.Column(gotKeys[0])
+	return c != nil && c.PrimaryKey && c.AutoIncrement
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"reflect"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
@@ -33,11 +34,11 @@ func ClientFrom(ctx context.Context) Client {
 	return c
 }
 
-// WithTransaction begins a new transaction with a *DBClient in the given
-// context, returning a new context with the *TxnClient.
+// WithTransaction begins a new transaction with a *DBClient or *RoutingClient
+// in the given context, returning a new context with the *TxnClient.
 func WithTransaction(ctx context.Context, opts *sql.TxOptions) (context.Context, *TxnClient, error) {
-	c, ok := ClientFrom(ctx).(*DBClient)
-	if !ok || c == nil {
+	c, ok := ClientFrom(ctx).(beginner)
+	if !ok || isNilClient(c) {
 		return ctx, nil, errors.New("no *schemable.DBClient in context.")
 	}
 
@@ -60,9 +61,11 @@ func WithDBDuration(ctx context.Context, start time.Time) context.Context {
 	return context.WithValue(ctx, dbDurKey, time.Since(start))
 }
 
-// DBDurationFrom extracts db execution duration from the given context.
+// DBDurationFrom extracts db execution duration from the given context, or 0
+// if it has none.
 func DBDurationFrom(ctx context.Context) time.Duration {
-	return ctx.Value(dbDurKey).(time.Duration)
+	d, _ := ctx.Value(dbDurKey).(time.Duration)
+	return d
 }
 
 // Select returns the Client with a SelectBuilder from the given context, or a
@@ -115,8 +118,26 @@ type noLogger struct{}
 func (l *noLogger) LogQuery(ctx context.Context, q string, args []any) {
 }
 
+func (l *noLogger) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+}
+
 var nilLogger = &noLogger{}
 
+// beginner is a Client that can start a transaction.
+type beginner interface {
+	Begin(ctx context.Context, opts *sql.TxOptions) (*TxnClient, error)
+}
+
+// isNilClient reports whether the client is nil, or a nil pointer like a
+// (*DBClient)(nil) in a non-nil interface.
+func isNilClient(c any) bool {
+	if c == nil {
+		return true
+	}
+	v := reflect.ValueOf(c)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
 
 var ErrNoClient = errors.New("no client in context")
 
@@ -125,4 +146,11 @@ type key int
 var (
 	clientKey = key(1)
 	dbDurKey = key(3)
+	primaryKey = key(4)
+	tagsKey = key(5)
+	idempotentKey = key(6)
+	eventKey = key(7)
+	identityKey = key(8)
+	releaseKey = key(9)
+	stmtKey = key(10)
 )
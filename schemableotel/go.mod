@@ -0,0 +1,22 @@
+module github.com/refractionist/schemable/schemableotel
+
+go 1.20
+
+require (
+	github.com/refractionist/schemable v0.1.0
+	go.opentelemetry.io/otel v1.24.0
+	go.opentelemetry.io/otel/trace v1.24.0
+)
+
+require (
+	github.com/Masterminds/squirrel v1.5.2 // indirect
+	github.com/go-logr/logr v1.4.1 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0 // indirect
+	github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
+	go.opentelemetry.io/otel/metric v1.24.0 // indirect
+)
+
+// builds in this repository use the local schemable. Modules that require
+// schemableotel ignore this and use the schemable version above.
+replace github.com/refractionist/schemable => ../
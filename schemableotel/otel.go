@@ -0,0 +1,79 @@
+// Package schemableotel adapts an OpenTelemetry trace.Tracer to a
+// schemable.Tracer. It is a separate module to keep schemable's go.mod tidy.
+package schemableotel
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/refractionist/schemable"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name is the instrumentation name for tracers from the global provider.
+const Name = "github.com/refractionist/schemable"
+
+// NewTracer returns a schemable.Tracer that starts client spans with the
+// given trace.Tracer, or one from the global TracerProvider if nil.
+func NewTracer(t trace.Tracer) schemable.Tracer {
+	if t == nil {
+		t = otel.Tracer(Name)
+	}
+	return &tracer{t: t}
+}
+
+type tracer struct {
+	t trace.Tracer
+}
+
+func (t *tracer) Start(ctx context.Context, name string, attrs ...schemable.Attribute) (context.Context, schemable.Span) {
+	ctx, s := t.t.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(convert(attrs)...),
+	)
+	return ctx, &span{s: s}
+}
+
+type span struct {
+	s trace.Span
+}
+
+func (s *span) SetAttributes(attrs ...schemable.Attribute) {
+	s.s.SetAttributes(convert(attrs)...)
+}
+
+func (s *span) End(err error) {
+	if err != nil {
+		s.s.RecordError(err)
+		s.s.SetStatus(codes.Error, err.Error())
+	}
+	s.s.End()
+}
+
+// convert returns OpenTelemetry attributes for the given schemable
+// attributes, formatting values of unknown types as strings.
+func convert(attrs []schemable.Attribute) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, len(attrs))
+	for i, a := range attrs {
+		switch v := a.Value.(type) {
+		case string:
+			kvs[i] = attribute.String(a.Key, v)
+		case bool:
+			kvs[i] = attribute.Bool(a.Key, v)
+		case int:
+			kvs[i] = attribute.Int(a.Key, v)
+		case int64:
+			kvs[i] = attribute.Int64(a.Key, v)
+		case float64:
+			kvs[i] = attribute.Float64(a.Key, v)
+		case []string:
+			kvs[i] = attribute.StringSlice(a.Key, v)
+		default:
+			kvs[i] = attribute.String(a.Key, fmt.Sprint(v))
+		}
+	}
+	return kvs
+}
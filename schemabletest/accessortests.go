@@ -0,0 +1,145 @@
+package schemabletest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/gen"
+)
+
+//go:generate go run ../cmd/schemable-accessors -type accessorTitle
+
+type accessorTitle struct {
+	ID     int64  `db:"id, PRIMARY KEY, AUTO INCREMENT"`
+	ID2    int64  `db:"id_two, PRIMARY KEY"`
+	Name   string `db:"name"`
+	Volume *int64 `db:"volume"`
+}
+
+var accessorTitles = schemable.Bind[accessorTitle]("comic_titles")
+
+// staleTitle has accessors that do not match its fields.
+type staleTitle struct {
+	ID   int64  `db:"id, PRIMARY KEY"`
+	Name string `db:"name"`
+}
+
+func (t *staleTitle) SchemableColumns() []string { return []string{"id"} }
+func (t *staleTitle) SchemableRefs() []any       { return []any{&t.ID} }
+func (t *staleTitle) SchemableValues() []any     { return []any{t.ID} }
+
+func AccessorTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Accessors", func(t *testing.T) {
+		if !accessorTitles.UsesAccessors() {
+			t.Error("generated accessors not used")
+		}
+		if ComicTitles.UsesAccessors() {
+			t.Error("accessors used without methods")
+		}
+		if schemable.Bind[staleTitle]("stale").UsesAccessors() {
+			t.Error("stale accessors used")
+		}
+
+		t.Run("Recorder", func(t *testing.T) {
+			ctx := schemable.WithClient(context.Background(), dc)
+			volume := int64(400)
+			rec := accessorTitles.Record(&accessorTitle{ID2: 400, Name: "accessors", Volume: &volume})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.ID == 0 {
+				t.Error("auto increment ID not set")
+			}
+			defer rec.Delete(ctx)
+
+			if ids := rec.WhereIDs(); ids["comic_titles.id"] != rec.Target.ID || ids["comic_titles.id_two"] != int64(400) {
+				t.Errorf("unexpected ids: %+v", ids)
+			}
+
+			*rec.Target.Volume = 401
+			if vals := rec.UpdatedValues(); len(vals) != 1 || vals["volume"] != int64(401) {
+				t.Errorf("unexpected updated values: %+v", vals)
+			}
+			if err := rec.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded := accessorTitles.Record(&accessorTitle{ID: rec.Target.ID, ID2: 400})
+			if err := loaded.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Target.Name != "accessors" || loaded.Target.Volume == nil || *loaded.Target.Volume != 401 {
+				t.Errorf("unexpected record: %+v", loaded.Target)
+			}
+
+			recs, err := accessorTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q.Where(sq.Eq{"id_two": 400})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != 1 || recs[0].Target.ID != rec.Target.ID {
+				t.Errorf("unexpected records: %+v", recs)
+			}
+		})
+
+		t.Run("gen.Accessors()", func(t *testing.T) {
+			dir := t.TempDir()
+			src := "package comics\n\n" +
+				"type Comic struct {\n" +
+				"\tID, Issue int64 `db:\"id, PRIMARY KEY\"`\n" +
+				"\tTitle *string `db:\"title\"`\n" +
+				"\tskipped string\n" +
+				"}\n\n" +
+				"type Publisher struct {\n" +
+				"\tName string `db:\"name, PRIMARY KEY\"`\n" +
+				"}\n"
+			if err := os.WriteFile(filepath.Join(dir, "comics.go"), []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := gen.Accessors(&buf, dir, "Comic"); err != nil {
+				t.Fatal(err)
+			}
+			want := "// Code generated by schemable-accessors. DO NOT EDIT.\n\n" +
+				"package comics\n\n" +
+				"// SchemableColumns returns the columns of Comic's db tagged fields.\n" +
+				"func (t *Comic) SchemableColumns() []string {\n" +
+				"\treturn []string{\"id\", \"id\", \"title\"}\n" +
+				"}\n\n" +
+				"// SchemableRefs returns pointers to Comic's db tagged fields.\n" +
+				"func (t *Comic) SchemableRefs() []any {\n" +
+				"\treturn []any{&t.ID, &t.Issue, &t.Title}\n" +
+				"}\n\n" +
+				"// SchemableValues returns the values of Comic's db tagged fields.\n" +
+				"func (t *Comic) SchemableValues() []any {\n" +
+				"\tvals := []any{t.ID, t.Issue, t.Title}\n" +
+				"\tif t.Title != nil {\n" +
+				"\t\tvals[2] = *t.Title\n" +
+				"\t}\n" +
+				"\treturn vals\n" +
+				"}\n"
+			if got := buf.String(); got != want {
+				t.Errorf("unexpected source:\n%s", got)
+			}
+
+			buf.Reset()
+			if err := gen.Accessors(&buf, dir); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(buf.Bytes(), []byte("func (t *Publisher) SchemableValues() []any {")) {
+				t.Errorf("missing Publisher accessors:\n%s", buf.String())
+			}
+
+			if err := gen.Accessors(&buf, dir, "Missing"); err == nil {
+				t.Error("expected error for missing struct")
+			}
+		})
+	})
+}
@@ -0,0 +1,279 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/schemabletest/fake"
+)
+
+func CacheTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("LRUCache", func(t *testing.T) {
+		ctx := context.Background()
+		lc := schemable.NewLRUCache(2)
+		lc.Set(ctx, "a", []byte("1"), 0)
+		lc.Set(ctx, "b", []byte("2"), 0)
+		if v, ok, err := lc.Get(ctx, "a"); err != nil || !ok || string(v) != "1" {
+			t.Errorf("unexpected value %q %v %v", v, ok, err)
+		}
+		lc.Set(ctx, "c", []byte("3"), 0)
+		if _, ok, _ := lc.Get(ctx, "b"); ok || lc.Len() != 2 {
+			t.Errorf("b not evicted")
+		}
+
+		lc.Set(ctx, "a", []byte("4"), time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+		if _, ok, _ := lc.Get(ctx, "a"); ok || lc.Len() != 1 {
+			t.Errorf("a not expired")
+		}
+		lc.Delete(ctx, "c")
+		if _, ok, _ := lc.Get(ctx, "c"); ok || lc.Len() != 0 {
+			t.Errorf("c not deleted")
+		}
+	})
+
+	t.Run("Schemer cache", func(t *testing.T) {
+		ctx := schemable.WithClient(context.Background(), dc)
+		lc := schemable.NewLRUCache(10)
+		cachedTitles := schemable.Bind[ComicTitle]("comic_titles")
+		cachedTitles.SetCache(lc, time.Minute)
+
+		rec := ComicTitles.Record(&ComicTitle{ID2: 490, Name: "cache", Volume: 1})
+		if err := rec.Insert(ctx); err != nil {
+			t.Fatal(err)
+		}
+		defer ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+			return q.Where(titleID2.Eq(490))
+		})
+
+		// renames the row without invalidating the cache
+		rename := func(t *testing.T, name string) {
+			t.Helper()
+			r, err := ComicTitles.Find(ctx, rec.Target.ID, 490)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Target.Name = name
+			if err := r.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expectName := func(t *testing.T, ctx context.Context, name string) *schemable.Recorder[ComicTitle] {
+			t.Helper()
+			r, err := cachedTitles.Find(ctx, rec.Target.ID, 490)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Target.Name != name || r.Target.Volume != 1 {
+				t.Errorf("unexpected record: %+v", r.Target)
+			}
+			return r
+		}
+
+		t.Run("Load()", func(t *testing.T) {
+			expectName(t, ctx, "cache")
+			if lc.Len() != 2 {
+				t.Errorf("unexpected cache size %d", lc.Len())
+			}
+			rename(t, "cache 2")
+			expectName(t, ctx, "cache")
+		})
+
+		t.Run("Update()", func(t *testing.T) {
+			r := expectName(t, ctx, "cache")
+			r.Target.Name = "cache 3"
+			if err := r.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+			expectName(t, ctx, "cache 3")
+		})
+
+		t.Run("DeleteWhere()", func(t *testing.T) {
+			if _, err := cachedTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where(titleID2.Eq(-1))
+			}); err != nil {
+				t.Fatal(err)
+			}
+			rename(t, "cache 4")
+			expectName(t, ctx, "cache 4")
+		})
+
+		t.Run("transactions", func(t *testing.T) {
+			rename(t, "cache 5")
+			expectName(t, ctx, "cache 4")
+
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := expectName(t, tctx, "cache 5")
+			r.Target.Name = "cache 6"
+			if err := r.Update(tctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			expectName(t, ctx, "cache 6")
+		})
+
+		t.Run("cache errors", func(t *testing.T) {
+			c := schemable.FromDB(dc.DB())
+			events := &eventLog{}
+			c.SetEventLogger(events)
+			ectx := schemable.WithClient(context.Background(), c)
+			failing := schemable.Bind[ComicTitle]("comic_titles")
+			failing.SetCache(failingCache{}, time.Minute)
+
+			r, err := failing.Find(ectx, rec.Target.ID, 490)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.Target.Volume = 2
+			if err := r.Update(ectx); err != nil {
+				t.Fatalf("cache error failed the update: %+v", err)
+			}
+			if e := events.last(t); e.Op != schemable.OpUpdate || e.Err != nil || !errors.Is(e.CacheErr, errCacheDown) {
+				t.Errorf("unexpected event: %+v", e)
+			}
+
+			r.Target.Volume = 1
+			if err := r.Update(ectx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := failing.DeleteWhere(ectx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where(titleID2.Eq(-1))
+			}); err != nil {
+				t.Fatalf("cache error failed DeleteWhere(): %+v", err)
+			}
+			if e := events.last(t); !errors.Is(e.CacheErr, errCacheDown) {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		})
+
+		t.Run("Scanner fields", func(t *testing.T) {
+			scanned := schemable.Bind[scannedTitle]("comic_titles")
+			scanned.SetCache(schemable.NewLRUCache(10), time.Minute)
+
+			r, err := scanned.Find(ctx, rec.Target.ID, 490)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Target.Name.s != "cache 6" {
+				t.Fatalf("unexpected record: %+v", r.Target)
+			}
+			rename(t, "cache 7")
+			r, err = scanned.Find(ctx, rec.Target.ID, 490)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r.Target.Name.s != "cache 6" || r.Target.Volume != 1 {
+				t.Errorf("unexpected cached record: %+v", r.Target)
+			}
+			rename(t, "cache 6")
+		})
+
+		t.Run("Delete()", func(t *testing.T) {
+			r := expectName(t, ctx, "cache 6")
+			if err := r.Delete(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cachedTitles.Find(ctx, rec.Target.ID, 490); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+	})
+
+	t.Run("stale fill", func(t *testing.T) {
+		// the fake database reads rows when queried, so an update in the
+		// middleware lands after the Load's read and before it is cached
+		c := fake.New()
+		if err := fake.Seed(c, ComicTitles, &ComicTitle{ID: 1, ID2: 490, Name: "stale"}); err != nil {
+			t.Fatal(err)
+		}
+		ctx := schemable.WithClient(context.Background(), c)
+		staleTitles := schemable.Bind[ComicTitle]("comic_titles")
+		staleTitles.SetCache(schemable.NewLRUCache(10), time.Minute)
+
+		var updated bool
+		c.Use(func(next schemable.Handler) schemable.Handler {
+			return func(qctx context.Context, stmt schemable.Statement) schemable.Response {
+				res := next(qctx, stmt)
+				if stmt.Kind == schemable.QueryRowStatement && !updated {
+					updated = true
+					r := staleTitles.Record(&ComicTitle{ID: 1, ID2: 490, Name: "fresh"})
+					if err := r.Update(ctx); err != nil {
+						t.Error(err)
+					}
+				}
+				return res
+			}
+		})
+
+		r, err := staleTitles.Find(ctx, 1, 490)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Target.Name != "stale" || !updated {
+			t.Fatalf("unexpected record: %+v", r.Target)
+		}
+		if r, err = staleTitles.Find(ctx, 1, 490); err != nil {
+			t.Fatal(err)
+		}
+		if r.Target.Name != "fresh" {
+			t.Errorf("stale record cached: %+v", r.Target)
+		}
+	})
+}
+
+// scannedTitle has a Scanner and Valuer field with unexported fields.
+type scannedTitle struct {
+	ID     int64       `db:"id, PRIMARY KEY, AUTO INCREMENT"`
+	ID2    int64       `db:"id_two, PRIMARY KEY"`
+	Name   scannedName `db:"name"`
+	Volume int         `db:"volume"`
+}
+
+type scannedName struct {
+	s string
+}
+
+func (n *scannedName) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		n.s = v
+	case []byte:
+		n.s = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into scannedName", src)
+	}
+	return nil
+}
+
+func (n scannedName) Value() (driver.Value, error) {
+	return n.s, nil
+}
+
+var errCacheDown = errors.New("cache down")
+
+// failingCache is a Cache that is always down.
+type failingCache struct{}
+
+func (failingCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return nil, false, errCacheDown
+}
+
+func (failingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return errCacheDown
+}
+
+func (failingCache) Delete(ctx context.Context, key string) error {
+	return errCacheDown
+}
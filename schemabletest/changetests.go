@@ -0,0 +1,136 @@
+package schemabletest
+
+import (
+	"context"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func ChangeTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Subscribe()", func(t *testing.T) {
+		ctx := schemable.WithClient(context.Background(), dc)
+		watchedTitles := schemable.Bind[ComicTitle]("comic_titles")
+		var changes []schemable.Change[ComicTitle]
+		var clients []schemable.Client
+		unsubscribe := watchedTitles.Subscribe(func(ctx context.Context, c schemable.Change[ComicTitle]) {
+			changes = append(changes, c)
+			clients = append(clients, schemable.ClientFrom(ctx))
+		})
+		defer unsubscribe()
+		expectChanges := func(t *testing.T, kinds ...schemable.ChangeKind) {
+			t.Helper()
+			if len(changes) != len(kinds) {
+				t.Fatalf("unexpected changes: %+v", changes)
+			}
+			for i, kind := range kinds {
+				if changes[i].Kind != kind || changes[i].Table != "comic_titles" {
+					t.Errorf("unexpected change %d: %+v", i, changes[i])
+				}
+			}
+		}
+
+		rec := watchedTitles.Record(&ComicTitle{ID2: 500, Name: "change", Volume: 1})
+		t.Run("writes", func(t *testing.T) {
+			changes = nil
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+			rec.Target.Name = "change 2"
+			if err := rec.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := rec.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+			expectChanges(t, schemable.Inserted, schemable.Updated)
+
+			if c := changes[0]; c.Target.Name != "change" || c.Target.ID != rec.Target.ID || c.Values["volume"] != 1 ||
+				c.Values["id"] != rec.Target.ID || c.Values["id_two"] != int64(500) {
+				t.Errorf("unexpected insert: %+v %+v", c.Target, c.Values)
+			}
+			if c := changes[1]; c.Target.Name != "change 2" || len(c.Values) != 1 || c.Values["name"] != "change 2" {
+				t.Errorf("unexpected update: %+v %+v", c.Target, c.Values)
+			}
+			if changes[0].Target == rec.Target {
+				t.Error("change target is not a copy")
+			}
+			if schemable.Inserted.String() != "inserted" || schemable.Deleted.String() != "deleted" {
+				t.Errorf("unexpected kinds: %s %s", schemable.Inserted, schemable.Deleted)
+			}
+		})
+
+		t.Run("transactions", func(t *testing.T) {
+			changes = nil
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec.Target.Volume = 2
+			if err := rec.Update(tctx); err != nil {
+				t.Fatal(err)
+			}
+			expectChanges(t)
+			if err := tc.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+			expectChanges(t)
+
+			tctx, tc, err = schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec.Target.Volume = 3
+			if err := rec.Update(tctx); err != nil {
+				t.Fatal(err)
+			}
+			expectChanges(t)
+			if err := tc.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			expectChanges(t, schemable.Updated)
+			if changes[0].Values["volume"] != 3 {
+				t.Errorf("unexpected update: %+v", changes[0].Values)
+			}
+			if c, ok := clients[len(clients)-1].(*schemable.DBClient); !ok || c != dc {
+				t.Errorf("unexpected subscriber client: %T", clients[len(clients)-1])
+			}
+		})
+
+		t.Run("deletes", func(t *testing.T) {
+			changes = nil
+			if err := rec.Delete(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := watchedTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where(titleID2.Eq(500))
+			}); err != nil {
+				t.Fatal(err)
+			}
+			expectChanges(t, schemable.Deleted, schemable.Deleted)
+			if changes[0].Target.ID != rec.Target.ID || changes[1].Target != nil {
+				t.Errorf("unexpected deletes: %+v", changes)
+			}
+		})
+
+		t.Run("unsubscribe", func(t *testing.T) {
+			changes = nil
+			dry := schemable.WithClient(ctx, schemable.NewDryRun())
+			if err := watchedTitles.Record(&ComicTitle{ID2: 500}).Insert(dry); err != nil {
+				t.Fatal(err)
+			}
+			expectChanges(t)
+
+			unsubscribe()
+			unsubscribe()
+			if err := watchedTitles.Record(&ComicTitle{ID2: 500}).Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+			defer ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where(titleID2.Eq(500))
+			})
+			expectChanges(t)
+		})
+	})
+}
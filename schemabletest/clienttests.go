@@ -84,6 +84,30 @@ func Run(t *testing.T, c *schemable.DBClient) {
 	})
 
 	TransactionTests(t, c)
+	RoutingTests(t, c)
+	MiddlewareTests(t, c)
+	EventTests(t, c)
+	SlogTests(t, c)
+	MetricsTests(t, c)
+	TracingTests(t, c)
+	CommentTests(t, c)
+	StmtCacheTests(t, c)
+	TimeoutTests(t, c)
+	RetryTests(t, c)
+	ReadOnlyTests(t, c)
+	DryRunTests(t, c)
+	FakeTests(t)
+	ReplayTests(t, c)
+	DDLTests(t, c)
+	MigrateTests(t, c)
+	SchemaTests(t, c)
+	GenTests(t, c)
+	AccessorTests(t, c)
+	ColumnTests(t, c)
+	PartialTests(t, c)
+	IdentityTests(t, c)
+	CacheTests(t, c)
+	ChangeTests(t, c)
 
 	t.Run("Targets()", func(t *testing.T) {
 		recs := []*schemable.Recorder[ComicTitle]{
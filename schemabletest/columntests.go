@@ -0,0 +1,125 @@
+package schemabletest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+var (
+	titleName   = schemable.ColumnOf[string](ComicTitles, "name")
+	titleVolume = schemable.ColumnOf[int](ComicTitles, "volume")
+	titleID2    = schemable.ColumnOf[int64](ComicTitles, "id_two")
+)
+
+func ColumnTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Column", func(t *testing.T) {
+		t.Run("predicates", func(t *testing.T) {
+			tests := []struct {
+				pred sq.Sqlizer
+				sql  string
+				args int
+			}{
+				{titleName.Eq("one"), "comic_titles.name = ?", 1},
+				{titleName.NotEq("one"), "comic_titles.name <> ?", 1},
+				{titleVolume.In(1, 2), "comic_titles.volume IN (?,?)", 2},
+				{titleVolume.NotIn(1, 2), "comic_titles.volume NOT IN (?,?)", 2},
+				{titleVolume.In(), "(1=0)", 0},
+				{titleVolume.Gt(1), "comic_titles.volume > ?", 1},
+				{titleVolume.GtOrEq(1), "comic_titles.volume >= ?", 1},
+				{titleVolume.Lt(1), "comic_titles.volume < ?", 1},
+				{titleVolume.LtOrEq(1), "comic_titles.volume <= ?", 1},
+				{titleName.Like("X-%"), "comic_titles.name LIKE ?", 1},
+				{titleName.NotLike("X-%"), "comic_titles.name NOT LIKE ?", 1},
+				{titleName.IsNull(), "comic_titles.name IS NULL", 0},
+				{titleName.IsNotNull(), "comic_titles.name IS NOT NULL", 0},
+				{ComicTitles.Col("name").Eq(1), "comic_titles.name = ?", 1},
+			}
+			for _, test := range tests {
+				sql, args, err := test.pred.ToSql()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if sql != test.sql || len(args) != test.args {
+					t.Errorf("unexpected predicate %q %v, want %q", sql, args, test.sql)
+				}
+			}
+
+			if titleName.Asc() != "comic_titles.name ASC" || titleName.Desc() != "comic_titles.name DESC" {
+				t.Errorf("unexpected order: %q %q", titleName.Asc(), titleName.Desc())
+			}
+			if titleName.Name() != "name" || titleName.String() != "comic_titles.name" {
+				t.Errorf("unexpected names: %q %q", titleName.Name(), titleName)
+			}
+		})
+
+		t.Run("invalid columns", func(t *testing.T) {
+			expectPanic(t, "has no column \"missing\"", func() {
+				ComicTitles.Col("missing")
+			})
+			expectPanic(t, "comic_titles.volume is int, not string", func() {
+				schemable.ColumnOf[string](ComicTitles, "volume")
+			})
+			type nullable struct {
+				Name *string `db:"name"`
+			}
+			schemable.ColumnOf[string](schemable.Bind[nullable]("nullables"), "name")
+			schemable.ColumnOf[int64](driftRecords, "id")
+			schemable.ColumnOf[string](driftRecords, "note")
+		})
+
+		t.Run("queries", func(t *testing.T) {
+			ctx := schemable.WithClient(context.Background(), dc)
+			for i, name := range []string{"X-Men", "X-Factor", "Excalibur"} {
+				rec := ComicTitles.Record(&ComicTitle{ID2: 460, Name: name, Volume: i + 1})
+				if err := rec.Insert(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			defer ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where(titleID2.Eq(460))
+			})
+
+			recs, err := ComicTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q.Where(titleID2.Eq(460)).Where(titleName.Like("X-%")).OrderBy(titleVolume.Desc())
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != 2 || recs[0].Target.Name != "X-Factor" || recs[1].Target.Name != "X-Men" {
+				t.Errorf("unexpected records: %+v", recs)
+			}
+
+			rec := ComicTitles.Record(nil)
+			if err := rec.LoadWhere(ctx, sq.And{titleID2.Eq(460), titleVolume.In(3, 4)}); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.Name != "Excalibur" {
+				t.Errorf("unexpected record: %+v", rec.Target)
+			}
+
+			ok, err := ComicTitles.Exists(ctx, sq.And{titleID2.Eq(460), titleVolume.Gt(3)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				t.Error("unexpected record with volume > 3")
+			}
+		})
+	})
+}
+
+func expectPanic(t *testing.T, msg string, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		r := recover()
+		if s, _ := r.(string); !strings.Contains(s, msg) {
+			t.Errorf("unexpected panic %v, want %q", r, msg)
+		}
+	}()
+	fn()
+}
@@ -0,0 +1,100 @@
+package schemabletest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/refractionist/schemable"
+)
+
+func CommentTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Query comments", func(t *testing.T) {
+		c := schemable.FromDB(dc.DB())
+		events := &eventLog{}
+		c.SetEventLogger(events)
+		c.SetQueryComments(true, map[string]string{"service": "comics"})
+		ctx := schemable.WithClient(context.Background(), c)
+
+		t.Run("static tags", func(t *testing.T) {
+			events.reset()
+			assertExists(t, ctx, ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1}))
+
+			e := events.last(t)
+			expected := "SELECT COUNT(*) > 0 FROM comic_titles WHERE comic_titles.id = ? AND comic_titles.id_two = ?" +
+				" /*operation='Exists',service='comics',table='comic_titles'*/"
+			if e.SQL != expected {
+				t.Errorf("unexpected sql: %q", e.SQL)
+			}
+		})
+
+		t.Run("WithQueryTags()", func(t *testing.T) {
+			tctx := schemable.WithQueryTags(ctx, map[string]string{
+				"route":   "/comics/:id",
+				"service": "override",
+			})
+			tctx = schemable.WithQueryTags(tctx, map[string]string{
+				"action": "it's",
+				"table":  "ignored",
+			})
+
+			if tags := schemable.QueryTagsFrom(tctx); len(tags) != 4 {
+				t.Errorf("unexpected tags: %+v", tags)
+			}
+
+			events.reset()
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    320,
+				Name:   "comments",
+				Volume: 320,
+			})
+			if err := rec.Insert(tctx); err != nil {
+				t.Fatal(err)
+			}
+
+			e := events.last(t)
+			expected := "INSERT INTO comic_titles (id_two,name,volume) VALUES (?,?,?)" +
+				" /*action='it%27s',operation='Insert',route='%2Fcomics%2F%3Aid',service='override',table='comic_titles'*/"
+			if e.SQL != expected {
+				t.Errorf("unexpected sql: %q", e.SQL)
+			}
+
+			if err := rec.Delete(tctx); err != nil {
+				t.Fatal(err)
+			}
+			refuteExists(t, ctx, rec)
+		})
+
+		t.Run("TxnClient", func(t *testing.T) {
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			events.reset()
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			if err := rec.Load(tctx); err != nil {
+				t.Fatal(err)
+			}
+
+			e := events.last(t)
+			expected := "SELECT comic_titles.name, comic_titles.volume FROM comic_titles WHERE comic_titles.id = ? AND comic_titles.id_two = ?" +
+				" /*operation='Load',service='comics',table='comic_titles'*/"
+			if e.SQL != expected {
+				t.Errorf("unexpected sql: %q", e.SQL)
+			}
+		})
+
+		t.Run("disabled", func(t *testing.T) {
+			c := schemable.FromDB(dc.DB())
+			events := &eventLog{}
+			c.SetEventLogger(events)
+			dctx := schemable.WithQueryTags(schemable.WithClient(context.Background(), c), map[string]string{"route": "/"})
+
+			assertExists(t, dctx, ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1}))
+			if e := events.last(t); e.SQL != "SELECT COUNT(*) > 0 FROM comic_titles WHERE comic_titles.id = ? AND comic_titles.id_two = ?" {
+				t.Errorf("unexpected sql: %q", e.SQL)
+			}
+		})
+	})
+}
@@ -0,0 +1,198 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/refractionist/schemable"
+)
+
+type ddlRecord struct {
+	ID       int64          `db:"id, PRIMARY KEY, AUTO INCREMENT"`
+	Code     string         `db:"code, SIZE 16, UNIQUE"`
+	Name     string         `db:"name, INDEX"`
+	Price    float64        `db:"price, TYPE DECIMAL(10,2), DEFAULT 0"`
+	Status   string         `db:"status, SIZE 8, DEFAULT 'new', INDEX ddl_records_status_idx"`
+	Kind     int32          `db:"kind, INDEX ddl_records_status_idx"`
+	Active   bool           `db:"active"`
+	Data     []byte         `db:"data"`
+	Note     *string        `db:"note"`
+	Rating   sql.NullInt64  `db:"rating"`
+	Created  time.Time      `db:"created"`
+	Deleted  sql.NullTime   `db:"deleted"`
+	Nickname sql.NullString `db:"nickname"`
+}
+
+var ddlRecords = schemable.Bind[ddlRecord]("ddl_records")
+
+func DDLTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("CreateTableSQL()", func(t *testing.T) {
+		t.Run("composite primary keys", func(t *testing.T) {
+			tests := map[schemable.Dialect]string{
+				schemable.SQLite: `CREATE TABLE comic_titles (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  id_two INTEGER NOT NULL,
+  name TEXT NOT NULL,
+  volume INTEGER NOT NULL,
+  UNIQUE (id, id_two)
+)`,
+				schemable.MySQL: `CREATE TABLE comic_titles (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  id_two BIGINT NOT NULL,
+  name TEXT NOT NULL,
+  volume BIGINT NOT NULL,
+  PRIMARY KEY (id, id_two)
+)`,
+				schemable.Postgres: `CREATE TABLE comic_titles (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+  id_two BIGINT NOT NULL,
+  name TEXT NOT NULL,
+  volume BIGINT NOT NULL,
+  PRIMARY KEY (id, id_two)
+)`,
+			}
+			for d, want := range tests {
+				stmts, err := ComicTitles.CreateTableSQL(d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(stmts) != 1 || stmts[0] != want {
+					t.Errorf("unexpected %s DDL:\n%s", d, strings.Join(stmts, ";\n"))
+				}
+			}
+
+			if _, err := ComicTitles.CreateTableSQL(schemable.UnknownDialect); err == nil {
+				t.Error("expected error for unknown dialect")
+			}
+		})
+
+		t.Run("types and options", func(t *testing.T) {
+			stmts, err := ddlRecords.CreateTableSQL(schemable.Postgres)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{`CREATE TABLE ddl_records (
+  id BIGINT GENERATED BY DEFAULT AS IDENTITY,
+  code VARCHAR(16) NOT NULL UNIQUE,
+  name TEXT NOT NULL,
+  price DECIMAL(10,2) NOT NULL DEFAULT 0,
+  status VARCHAR(8) NOT NULL DEFAULT 'new',
+  kind INTEGER NOT NULL,
+  active BOOLEAN NOT NULL,
+  data BYTEA NOT NULL,
+  note TEXT,
+  rating BIGINT,
+  created TIMESTAMP WITH TIME ZONE NOT NULL,
+  deleted TIMESTAMP WITH TIME ZONE,
+  nickname TEXT,
+  PRIMARY KEY (id)
+)`,
+				"CREATE INDEX ddl_records_name_idx ON ddl_records (name)",
+				"CREATE INDEX ddl_records_status_idx ON ddl_records (status, kind)",
+			}
+			if strings.Join(stmts, ";\n") != strings.Join(want, ";\n") {
+				t.Errorf("unexpected DDL:\n%s", strings.Join(stmts, ";\n"))
+			}
+
+			stmts, err = ddlRecords.CreateTableSQL(schemable.MySQL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, col := range []string{"name VARCHAR(255) NOT NULL", "data LONGBLOB NOT NULL", "created DATETIME(6) NOT NULL"} {
+				if !strings.Contains(stmts[0], col) {
+					t.Errorf("missing %q in MySQL DDL:\n%s", col, stmts[0])
+				}
+			}
+		})
+
+		t.Run("unsigned types", func(t *testing.T) {
+			type unsignedRecord struct {
+				Tiny  uint8  `db:"tiny"`
+				Small uint16 `db:"small"`
+				Int   uint32 `db:"int"`
+				Big   uint64 `db:"big"`
+				Word  *uint  `db:"word"`
+			}
+			schemer := schemable.Bind[unsignedRecord]("unsigned_records")
+			wants := map[schemable.Dialect][]string{
+				schemable.SQLite: {"tiny INTEGER NOT NULL", "small INTEGER NOT NULL", "int INTEGER NOT NULL", "big INTEGER NOT NULL", "word INTEGER\n"},
+				schemable.MySQL: {"tiny TINYINT UNSIGNED NOT NULL", "small SMALLINT UNSIGNED NOT NULL", "int INT UNSIGNED NOT NULL",
+					"big BIGINT UNSIGNED NOT NULL", "word BIGINT UNSIGNED\n"},
+				schemable.Postgres: {"tiny SMALLINT NOT NULL", "small INTEGER NOT NULL", "int BIGINT NOT NULL",
+					"big NUMERIC(20) NOT NULL", "word NUMERIC(20)\n"},
+			}
+			for d, cols := range wants {
+				stmts, err := schemer.CreateTableSQL(d)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, col := range cols {
+					if !strings.Contains(stmts[0], col) {
+						t.Errorf("missing %q in %s DDL:\n%s", col, d, stmts[0])
+					}
+				}
+			}
+		})
+
+		t.Run("unsupported type", func(t *testing.T) {
+			type badRecord struct {
+				Tags []string `db:"tags"`
+			}
+			_, err := schemable.Bind[badRecord]("bad_records").CreateTableSQL(schemable.SQLite)
+			if err == nil || !strings.Contains(err.Error(), "TYPE tag option") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		d := dc.Dialect()
+		if d == schemable.UnknownDialect {
+			return
+		}
+
+		t.Run("Exec()", func(t *testing.T) {
+			ctx := schemable.WithClient(context.Background(), dc)
+			stmts, err := ddlRecords.CreateTableSQL(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, stmt := range stmts {
+				if _, err := dc.Exec(ctx, stmt); err != nil {
+					t.Fatalf("%s: %s", err, stmt)
+				}
+			}
+			defer dc.Exec(ctx, "DROP TABLE ddl_records")
+
+			note := "note"
+			rec := ddlRecords.Record(&ddlRecord{
+				Code:    "a1",
+				Name:    "ddl",
+				Price:   1.5,
+				Status:  "new",
+				Active:  true,
+				Data:    []byte("data"),
+				Note:    &note,
+				Rating:  sql.NullInt64{Int64: 5, Valid: true},
+				Created: time.Now().UTC().Truncate(time.Second),
+			})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded := ddlRecords.Record(&ddlRecord{ID: rec.Target.ID})
+			if err := loaded.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if l := loaded.Target; l.Code != "a1" || *l.Note != "note" || l.Rating.Int64 != 5 || l.Deleted.Valid || !l.Created.Equal(rec.Target.Created) {
+				t.Errorf("unexpected record: %+v", l)
+			}
+
+			dupe := ddlRecords.Record(&ddlRecord{Code: "a1", Data: []byte("x"), Created: time.Now()})
+			if err := dupe.Insert(ctx); err == nil {
+				t.Error("expected unique constraint error")
+			}
+		})
+	})
+}
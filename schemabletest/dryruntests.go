@@ -0,0 +1,164 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func DryRunTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("DryRunClient", func(t *testing.T) {
+		dr := schemable.NewDryRun()
+		ctx := schemable.WithClient(context.Background(), dr)
+
+		rec := ComicTitles.Record(&ComicTitle{
+			ID2:    370,
+			Name:   "dry run",
+			Volume: 370,
+		})
+		if err := rec.Insert(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Target.ID != 1 {
+			t.Errorf("unexpected fake insert ID: %d", rec.Target.ID)
+		}
+
+		rec.Target.Volume = 371
+		if err := rec.Update(ctx); err != nil {
+			t.Fatal(err)
+		}
+		n, err := ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+			return q.Where("id_two = ?", 370)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if affected, _ := n.RowsAffected(); affected != 1 {
+			t.Errorf("unexpected rows affected: %d", affected)
+		}
+
+		if err := rec.Load(ctx); err != sql.ErrNoRows {
+			t.Errorf("unexpected Load() error: %+v", err)
+		}
+		recs, err := ComicTitles.List(ctx, 10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recs) != 0 {
+			t.Errorf("unexpected records: %+v", recs)
+		}
+
+		stmts := dr.Statements()
+		kinds := []schemable.StatementKind{
+			schemable.ExecStatement,
+			schemable.ExecStatement,
+			schemable.ExecStatement,
+			schemable.QueryRowStatement,
+			schemable.QueryStatement,
+		}
+		if len(stmts) != len(kinds) {
+			t.Fatalf("unexpected statements: %+v", stmts)
+		}
+		for i, stmt := range stmts {
+			if stmt.Kind != kinds[i] {
+				t.Errorf("unexpected statement %d: %+v", i, stmt)
+			}
+		}
+		if stmt := stmts[1]; !strings.HasPrefix(stmt.SQL, "UPDATE comic_titles SET volume = ?") || len(stmt.Args) != 3 || stmt.Args[0] != 371 {
+			t.Errorf("unexpected update: %+v", stmt)
+		}
+
+		var plan strings.Builder
+		if err := dr.WritePlan(&plan); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(plan.String()), "\n")
+		if len(lines) != 5 {
+			t.Fatalf("unexpected plan:\n%s", plan.String())
+		}
+		if l := lines[0]; l != `1. INSERT INTO comic_titles (id_two,name,volume) VALUES (?,?,?) -- [370, "dry run", 370]` {
+			t.Errorf("unexpected plan line: %s", l)
+		}
+		if l := lines[2]; l != `3. DELETE FROM comic_titles WHERE id_two = ? -- [370]` {
+			t.Errorf("unexpected plan line: %s", l)
+		}
+
+		t.Run("SetResult()", func(t *testing.T) {
+			dr.SetResult(func(stmt schemable.Statement) sql.Result {
+				return schemable.DryRunResult{InsertID: 42, Affected: 7}
+			})
+			defer dr.SetResult(nil)
+
+			rec := ComicTitles.Record(&ComicTitle{ID2: 370, Name: "dry run"})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.ID != 42 {
+				t.Errorf("unexpected insert ID: %d", rec.Target.ID)
+			}
+
+			dr.SetResult(func(stmt schemable.Statement) sql.Result { return nil })
+			rec.Target.Name = "nil result"
+			if err := rec.Update(ctx); err != nil {
+				t.Errorf("unexpected Update() error: %+v", err)
+			}
+			if err := ComicTitles.Record(&ComicTitle{ID2: 370}).Insert(ctx); err == nil {
+				t.Error("expected Insert() error without a result")
+			}
+		})
+
+		t.Run("transactions", func(t *testing.T) {
+			dr.Reset()
+			var changes int
+			unsubscribe := ComicTitles.Subscribe(func(ctx context.Context, c schemable.Change[ComicTitle]) {
+				changes++
+			})
+			defer unsubscribe()
+
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := ComicTitles.Record(&ComicTitle{ID2: 370, Name: "dry run txn"})
+			if err := rec.Insert(tctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := rec.Load(tctx); err != sql.ErrNoRows {
+				t.Errorf("unexpected Load() error: %+v", err)
+			}
+			if err := tc.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			if stmts := dr.Statements(); len(stmts) != 2 || stmts[0].Kind != schemable.ExecStatement {
+				t.Errorf("unexpected statements: %+v", stmts)
+			}
+			if changes != 0 {
+				t.Errorf("dry run transaction sent %d changes", changes)
+			}
+
+			tctx, tc, err = schemable.WithTransaction(ctx, &sql.TxOptions{ReadOnly: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+			if err := rec.Insert(tctx); !errors.Is(err, schemable.ErrReadOnly) {
+				t.Errorf("unexpected read only Insert() error: %+v", err)
+			}
+		})
+
+		t.Run("Reset()", func(t *testing.T) {
+			dr.Reset()
+			if stmts := dr.Statements(); len(stmts) != 0 {
+				t.Errorf("unexpected statements: %+v", stmts)
+			}
+		})
+
+		// nothing was written to the real database
+		refuteExists(t, schemable.WithClient(context.Background(), dc), rec)
+	})
+}
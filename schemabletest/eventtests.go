@@ -0,0 +1,192 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func EventTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("QueryEvent", func(t *testing.T) {
+		c := schemable.FromDB(dc.DB())
+		events := &eventLog{}
+		c.SetEventLogger(events)
+		ctx := schemable.WithClient(context.Background(), c)
+
+		t.Run("Insert()", func(t *testing.T) {
+			events.reset()
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    280,
+				Name:   "events",
+				Volume: 280,
+			})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			e := events.last(t)
+			if e.Op != schemable.OpInsert {
+				t.Errorf("unexpected op: %q", e.Op)
+			}
+			if e.Table != "comic_titles" {
+				t.Errorf("unexpected table: %q", e.Table)
+			}
+			if e.SQL != "INSERT INTO comic_titles (id_two,name,volume) VALUES (?,?,?)" {
+				t.Errorf("unexpected sql: %q", e.SQL)
+			}
+			if len(e.Args) != 3 {
+				t.Errorf("unexpected args: %+v", e.Args)
+			}
+			if e.RowsAffected != 1 {
+				t.Errorf("unexpected rows affected: %d", e.RowsAffected)
+			}
+			if e.Err != nil || e.InTx || e.Duration <= 0 {
+				t.Errorf("unexpected event: %+v", e)
+			}
+
+			t.Run("Delete()", func(t *testing.T) {
+				events.reset()
+				if err := rec.Delete(ctx); err != nil {
+					t.Fatal(err)
+				}
+
+				e := events.last(t)
+				if e.Op != schemable.OpDelete || e.RowsAffected != 1 {
+					t.Errorf("unexpected event: %+v", e)
+				}
+			})
+		})
+
+		t.Run("Load()", func(t *testing.T) {
+			t.Run("missing row", func(t *testing.T) {
+				events.reset()
+				rec := ComicTitles.Record(&ComicTitle{ID: 1000, ID2: 1000})
+				if err := rec.Load(ctx); err != sql.ErrNoRows {
+					t.Fatalf("unexpected error: %+v", err)
+				}
+
+				e := events.last(t)
+				if e.Op != schemable.OpSelect || e.Err != sql.ErrNoRows || e.RowsReturned != 0 {
+					t.Errorf("unexpected event: %+v", e)
+				}
+			})
+
+			events.reset()
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			e := events.last(t)
+			if e.Op != schemable.OpSelect || e.Err != nil || e.RowsReturned != 1 {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		})
+
+		t.Run("ListWhere()", func(t *testing.T) {
+			events.reset()
+			recs, err := ComicTitles.List(ctx, 2, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			e := events.last(t)
+			if e.RowsReturned != int64(len(recs)) || e.RowsReturned == 0 {
+				t.Errorf("unexpected event: %+v", e)
+			}
+
+			t.Run("with error", func(t *testing.T) {
+				events.reset()
+				_, err := ComicTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+					return q.Where("missing_column = 1")
+				})
+				if err == nil {
+					t.Fatal("expected error")
+				}
+
+				e := events.last(t)
+				if e.Err != err || e.SQL == "" {
+					t.Errorf("unexpected event: %+v", e)
+				}
+			})
+		})
+
+		t.Run("DeleteWhere() with error", func(t *testing.T) {
+			events.reset()
+			_, err := ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where("missing_column = 1")
+			})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+
+			e := events.last(t)
+			if e.Op != schemable.OpDelete || e.Err != err {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		})
+
+		t.Run("TxnClient", func(t *testing.T) {
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			events.reset()
+			assertExists(t, tctx, ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1}))
+
+			e := events.last(t)
+			if !e.InTx || e.RowsReturned != 1 {
+				t.Errorf("unexpected event: %+v", e)
+			}
+		})
+
+		t.Run("QueryLogger", func(t *testing.T) {
+			c := schemable.FromDB(dc.DB())
+			l := &queryLog{}
+			c.SetLogger(l)
+			ctx := schemable.WithClient(context.Background(), c)
+
+			assertExists(t, ctx, ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1}))
+			if len(l.queries) != 1 {
+				t.Fatalf("unexpected queries: %+v", l.queries)
+			}
+			if l.queries[0] != "SELECT COUNT(*) > 0 FROM comic_titles WHERE comic_titles.id = ? AND comic_titles.id_two = ?" {
+				t.Errorf("unexpected query: %q", l.queries[0])
+			}
+		})
+	})
+}
+
+type eventLog struct {
+	events []*schemable.QueryEvent
+}
+
+func (l *eventLog) LogQueryEvent(ctx context.Context, e *schemable.QueryEvent) {
+	l.events = append(l.events, e)
+}
+
+func (l *eventLog) reset() {
+	l.events = nil
+}
+
+func (l *eventLog) last(t *testing.T) *schemable.QueryEvent {
+	t.Helper()
+	if len(l.events) == 0 {
+		t.Fatal("no events logged")
+	}
+	return l.events[len(l.events)-1]
+}
+
+type queryLog struct {
+	queries []string
+}
+
+func (l *queryLog) LogQuery(ctx context.Context, q string, args []any) {
+	schemable.DBDurationFrom(ctx)
+	l.queries = append(l.queries, q)
+}
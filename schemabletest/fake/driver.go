@@ -0,0 +1,167 @@
+package fake
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+)
+
+// connector opens connections to a database in memory.
+type connector struct {
+	db *database
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{db: c.db}, nil
+}
+
+func (c connector) Driver() driver.Driver {
+	return fakeDriver{db: c.db}
+}
+
+type fakeDriver struct {
+	db *database
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+	return &conn{db: d.db}, nil
+}
+
+type conn struct {
+	db *database
+	tx *tx
+}
+
+func (c *conn) Prepare(q string) (driver.Stmt, error) {
+	return &stmt{conn: c, q: q}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if c.tx != nil {
+		return nil, errors.New("fake: transaction already in progress")
+	}
+	c.tx = &tx{conn: c, snapshot: c.db.snapshot(), readOnly: opts.ReadOnly}
+	return c.tx, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.tx != nil && c.tx.readOnly {
+		if s, err := parse(q, values(args)); err == nil && s.kind != selectStmt {
+			return nil, errReadOnly
+		}
+	}
+	return c.db.exec(q, values(args))
+}
+
+func (c *conn) QueryContext(ctx context.Context, q string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.db.query(q, values(args))
+}
+
+type tx struct {
+	conn     *conn
+	snapshot map[string]*table
+	readOnly bool
+}
+
+func (t *tx) Commit() error {
+	t.conn.tx = nil
+	return nil
+}
+
+// Rollback restores the tables as they were when the transaction began,
+// including changes made outside of the transaction since then.
+func (t *tx) Rollback() error {
+	t.conn.db.restore(t.snapshot)
+	t.conn.tx = nil
+	return nil
+}
+
+type stmt struct {
+	conn *conn
+	q    string
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return -1
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.db.exec(s.q, args)
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.db.query(s.q, args)
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.q, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.q, args)
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+	next    int
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.next])
+	r.next++
+	return nil
+}
+
+func values(args []driver.NamedValue) []driver.Value {
+	vals := make([]driver.Value, len(args))
+	for i, a := range args {
+		vals[i] = a.Value
+	}
+	return vals
+}
+
+var errReadOnly = errors.New("fake: attempt to write a readonly database")
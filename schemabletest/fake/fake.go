@@ -0,0 +1,478 @@
+// Package fake is an in-memory database for testing code that uses schemable
+// without a SQL driver. It understands the statements built by a Schemer or
+// Recorder, and simple predicates from WhereFuncs and DeleteFuncs.
+//
+//	c := fake.New()
+//	fake.Register(c, ComicTitles)
+//	fake.Seed(c, ComicTitles, &ComicTitle{Name: "one"})
+//
+//	ctx := schemable.WithClient(context.Background(), c)
+//	rec, err := ComicTitles.First(ctx, byName("one"))
+package fake
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"sync"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+// Client is a schemable Client backed by tables in memory. It supports
+// everything that a *schemable.DBClient does, including transactions,
+// though they are not isolated: Rollback restores every table to its state
+// when the transaction began.
+type Client struct {
+	*schemable.DBClient
+	db *database
+}
+
+// New returns a Client with an empty database. Tables are created with
+// Register or SeedRows. Its dialect is SQLite, whose placeholders and error
+// messages the fake database uses.
+func New() *Client {
+	db := &database{tables: make(map[string]*table)}
+	c := &Client{
+		DBClient: schemable.FromDB(sql.OpenDB(connector{db: db})),
+		db:       db,
+	}
+	c.SetDialect(schemable.SQLite)
+	return c
+}
+
+// Register creates the table of the given Schemer, with its primary keys and
+// auto increment column. Inserts fail if a row with the same primary keys
+// exists. Registering a table again removes its rows.
+func Register[T any](c *Client, s *schemable.Schemer[T]) {
+	t := &table{name: s.Table(), registered: true}
+	insertable := make(map[string]bool)
+	for _, col := range s.InsertColumns() {
+		insertable[col] = true
+	}
+	for _, col := range s.Columns(true) {
+		col = unprefix(col)
+		t.columns = append(t.columns, col)
+		if !insertable[col] {
+			t.auto = col
+		}
+	}
+	for col := range s.Record(nil).WhereIDs() {
+		t.keys = append(t.keys, unprefix(col))
+	}
+	sort.Strings(t.keys)
+
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.tables[t.name] = t
+}
+
+// Seed inserts the given targets with the Schemer, registering its table
+// first if needed. Auto increment IDs are set on the targets.
+func Seed[T any](c *Client, s *schemable.Schemer[T], targets ...*T) error {
+	if !c.HasTable(s.Table()) {
+		Register(c, s)
+	}
+	ctx := schemable.WithClient(context.Background(), c)
+	for _, tgt := range targets {
+		if err := s.Record(tgt).Insert(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// All returns every row of the Schemer's table, in insertion order.
+func All[T any](c *Client, s *schemable.Schemer[T]) ([]*T, error) {
+	ctx := schemable.WithClient(context.Background(), c)
+	recs, err := s.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+		return q
+	})
+	return schemable.Targets(recs), err
+}
+
+// SeedRows inserts rows of column values into the given table, creating it
+// if needed. Tables created this way have no primary keys.
+func (c *Client) SeedRows(name string, rows ...map[string]any) error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	t, ok := c.db.tables[name]
+	if !ok {
+		t = &table{name: name}
+		c.db.tables[name] = t
+	}
+	for _, r := range rows {
+		row := make(map[string]driver.Value, len(r))
+		for col, v := range r {
+			dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+			if err != nil {
+				return fmt.Errorf("fake: column %q: %w", col, err)
+			}
+			if !t.hasColumn(col) {
+				if t.registered {
+					return fmt.Errorf("fake: table %s has no column named %s", name, col)
+				}
+				t.columns = append(t.columns, col)
+			}
+			row[col] = dv
+		}
+		if _, err := t.insert(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rows returns copies of the rows in the given table as column values, in
+// insertion order. Integers are int64 and floats are float64.
+func (c *Client) Rows(name string) []map[string]any {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+
+	t, ok := c.db.tables[name]
+	if !ok {
+		return nil
+	}
+	rows := make([]map[string]any, len(t.rows))
+	for i, r := range t.rows {
+		rows[i] = make(map[string]any, len(t.columns))
+		for _, col := range t.columns {
+			rows[i][col] = r[col]
+		}
+	}
+	return rows
+}
+
+// HasTable returns true if the given table exists.
+func (c *Client) HasTable(name string) bool {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	_, ok := c.db.tables[name]
+	return ok
+}
+
+// Truncate removes the rows of every table, and resets auto increment IDs.
+func (c *Client) Truncate() {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	for _, t := range c.db.tables {
+		t.rows = nil
+		t.lastID = 0
+	}
+}
+
+// database is the shared state of a Client's connections.
+type database struct {
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+type table struct {
+	name    string
+	columns []string
+	keys    []string
+	auto    string
+	rows    []map[string]driver.Value
+	lastID  int64
+	// registered tables have a fixed set of columns
+	registered bool
+}
+
+func (t *table) hasColumn(col string) bool {
+	for _, c := range t.columns {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *table) checkColumns(cols []string) error {
+	for _, col := range cols {
+		if !t.hasColumn(col) {
+			return fmt.Errorf("fake: table %s has no column named %s", t.name, col)
+		}
+	}
+	return nil
+}
+
+// insert adds the row, setting its auto increment column if needed.
+func (t *table) insert(row map[string]driver.Value) (int64, error) {
+	if t.auto != "" {
+		if id, ok := row[t.auto].(int64); ok {
+			if id > t.lastID {
+				t.lastID = id
+			}
+		} else {
+			t.lastID++
+			row[t.auto] = t.lastID
+		}
+	}
+
+	if len(t.keys) > 0 {
+		for _, existing := range t.rows {
+			if sameKeys(t.keys, existing, row) {
+				return 0, fmt.Errorf("fake: UNIQUE constraint failed: %s", t.name)
+			}
+		}
+	}
+
+	t.rows = append(t.rows, row)
+	if t.auto != "" {
+		id, _ := row[t.auto].(int64)
+		return id, nil
+	}
+	return int64(len(t.rows)), nil
+}
+
+func sameKeys(keys []string, a, b map[string]driver.Value) bool {
+	for _, k := range keys {
+		if c, ok := compare(a[k], b[k]); !ok || c != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *table) clone() *table {
+	c := *t
+	c.columns = append([]string(nil), t.columns...)
+	c.keys = append([]string(nil), t.keys...)
+	c.rows = make([]map[string]driver.Value, len(t.rows))
+	for i, r := range t.rows {
+		c.rows[i] = make(map[string]driver.Value, len(r))
+		for k, v := range r {
+			c.rows[i][k] = v
+		}
+	}
+	return &c
+}
+
+func (db *database) snapshot() map[string]*table {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	tables := make(map[string]*table, len(db.tables))
+	for name, t := range db.tables {
+		tables[name] = t.clone()
+	}
+	return tables
+}
+
+func (db *database) restore(tables map[string]*table) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tables = tables
+}
+
+func (db *database) table(name string) (*table, error) {
+	t, ok := db.tables[name]
+	if !ok {
+		return nil, fmt.Errorf("fake: no such table: %s", name)
+	}
+	return t, nil
+}
+
+func (db *database) exec(q string, args []driver.Value) (driver.Result, error) {
+	s, err := parse(q, args)
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, err := db.table(s.table)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.checkColumns(s.columns); err != nil {
+		return nil, err
+	}
+	if s.where != nil {
+		if err := t.checkColumns(s.where.columns()); err != nil {
+			return nil, err
+		}
+	}
+
+	switch s.kind {
+	case insertStmt:
+		res := result{}
+		for _, vals := range s.values {
+			row := make(map[string]driver.Value, len(t.columns))
+			for i, col := range s.columns {
+				row[col] = vals[i].eval(row)
+			}
+			if res.lastInsertID, err = t.insert(row); err != nil {
+				return res, err
+			}
+			res.rowsAffected++
+		}
+		return res, nil
+
+	case updateStmt:
+		res := result{}
+		for _, row := range t.rows {
+			if s.where != nil && !s.where.match(row) {
+				continue
+			}
+			updated := make([]driver.Value, len(s.columns))
+			for i := range s.columns {
+				updated[i] = s.values[0][i].eval(row)
+			}
+			for i, col := range s.columns {
+				row[col] = updated[i]
+			}
+			res.rowsAffected++
+		}
+		return res, nil
+
+	case deleteStmt:
+		res := result{}
+		kept := t.rows[:0]
+		for _, row := range t.rows {
+			if s.where != nil && !s.where.match(row) {
+				kept = append(kept, row)
+				continue
+			}
+			res.rowsAffected++
+		}
+		for i := len(kept); i < len(t.rows); i++ {
+			t.rows[i] = nil
+		}
+		t.rows = kept
+		return res, nil
+	}
+
+	// a SELECT through Exec
+	_, err = t.query(s)
+	return result{}, err
+}
+
+func (db *database) query(q string, args []driver.Value) (driver.Rows, error) {
+	s, err := parse(q, args)
+	if err != nil {
+		return nil, err
+	}
+	if s.kind != selectStmt {
+		if _, err := db.exec(q, args); err != nil {
+			return nil, err
+		}
+		return &rows{}, nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	t, err := db.table(s.table)
+	if err != nil {
+		return nil, err
+	}
+	return t.query(s)
+}
+
+// query runs the select statement.
+func (t *table) query(s *parsed) (*rows, error) {
+	var cols []string
+	for _, item := range s.items {
+		if item.column != "" {
+			cols = append(cols, item.column)
+		}
+	}
+	if s.where != nil {
+		cols = append(cols, s.where.columns()...)
+	}
+	for _, o := range s.orderBy {
+		cols = append(cols, o.column)
+	}
+	if err := t.checkColumns(cols); err != nil {
+		return nil, err
+	}
+
+	var matched []map[string]driver.Value
+	for _, row := range t.rows {
+		if s.where == nil || s.where.match(row) {
+			matched = append(matched, row)
+		}
+	}
+
+	if len(s.orderBy) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, o := range s.orderBy {
+				a, b := matched[i][o.column], matched[j][o.column]
+				c, ok := compare(a, b)
+				if !ok {
+					// NULLs sort first
+					if a == nil && b != nil {
+						return !o.desc
+					}
+					if b == nil && a != nil {
+						return o.desc
+					}
+					continue
+				}
+				if c != 0 {
+					return (c < 0) != o.desc
+				}
+			}
+			return false
+		})
+	}
+
+	res := &rows{}
+	for _, item := range s.items {
+		switch {
+		case item.star:
+			res.columns = append(res.columns, t.columns...)
+		case item.count:
+			res.columns = append(res.columns, "COUNT(*)")
+		default:
+			res.columns = append(res.columns, item.column)
+		}
+	}
+
+	if s.items[0].count {
+		var n driver.Value = int64(len(matched))
+		if item := s.items[0]; item.op != "" {
+			n = cmpExpr{left: operand{value: n}, op: item.op, right: item.val}.match(nil)
+		}
+		matched = []map[string]driver.Value{{"COUNT(*)": n}}
+	}
+
+	if s.offset >= int64(len(matched)) {
+		matched = nil
+	} else {
+		matched = matched[s.offset:]
+	}
+	if s.limit >= 0 && s.limit < int64(len(matched)) {
+		matched = matched[:s.limit]
+	}
+
+	for _, row := range matched {
+		vals := make([]driver.Value, 0, len(res.columns))
+		for _, item := range s.items {
+			switch {
+			case item.star:
+				for _, col := range t.columns {
+					vals = append(vals, row[col])
+				}
+			case item.count:
+				vals = append(vals, row["COUNT(*)"])
+			default:
+				vals = append(vals, row[item.column])
+			}
+		}
+		res.values = append(res.values, vals)
+	}
+	return res, nil
+}
+
+func unprefix(col string) string {
+	for i := len(col) - 1; i >= 0; i-- {
+		if col[i] == '.' {
+			return col[i+1:]
+		}
+	}
+	return col
+}
@@ -0,0 +1,938 @@
+package fake
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The fake understands the statements that a Schemer or Recorder builds:
+//
+//	SELECT columns FROM table [WHERE expr] [ORDER BY column [ASC|DESC], ...] [LIMIT n] [OFFSET n]
+//	SELECT COUNT(*) [op value] FROM table [WHERE expr]
+//	INSERT INTO table (columns) VALUES (values), ...
+//	UPDATE table SET column = value, ... [WHERE expr]
+//	DELETE FROM table [WHERE expr]
+//
+// Where expressions combine comparisons (=, <>, !=, <, <=, >, >=), IN, LIKE,
+// and IS NULL with AND, OR, NOT, and parentheses, like the predicates from
+// squirrel's Eq, NotEq, Lt, Gt, Like, And, and Or.
+
+type stmtKind int
+
+const (
+	selectStmt stmtKind = iota
+	insertStmt
+	updateStmt
+	deleteStmt
+)
+
+// parsed is a statement with its args bound.
+type parsed struct {
+	kind    stmtKind
+	table   string
+	columns []string
+	values  [][]operand // insert values, or one row of update values
+	items   []selectItem
+	where   expr
+	orderBy []order
+	limit   int64
+	offset  int64
+}
+
+type selectItem struct {
+	column string
+	star   bool
+	count  bool
+	// comparison for COUNT(*) > 0
+	op  string
+	val operand
+}
+
+type order struct {
+	column string
+	desc   bool
+}
+
+// operand is a column reference, a constant value, or arithmetic on two
+// operands.
+type operand struct {
+	column string
+	value  driver.Value
+	op     byte
+	args   []operand
+}
+
+func (o operand) eval(row map[string]driver.Value) driver.Value {
+	switch {
+	case o.op != 0:
+		return arithmetic(o.op, o.args[0].eval(row), o.args[1].eval(row))
+	case o.column != "":
+		return row[o.column]
+	}
+	return o.value
+}
+
+// arithmetic applies +, -, *, or / to numbers, returning NULL for other
+// values.
+func arithmetic(op byte, a, b driver.Value) driver.Value {
+	ai, aInt := a.(int64)
+	bi, bInt := b.(int64)
+	if aInt && bInt {
+		switch op {
+		case '+':
+			return ai + bi
+		case '-':
+			return ai - bi
+		case '*':
+			return ai * bi
+		case '/':
+			if bi == 0 {
+				return nil
+			}
+			return ai / bi
+		}
+	}
+
+	af, ok1 := number(a)
+	bf, ok2 := number(b)
+	if !ok1 || !ok2 {
+		return nil
+	}
+	switch op {
+	case '+':
+		return af + bf
+	case '-':
+		return af - bf
+	case '*':
+		return af * bf
+	case '/':
+		if bf == 0 {
+			return nil
+		}
+		return af / bf
+	}
+	return nil
+}
+
+// expr is a where condition.
+type expr interface {
+	match(row map[string]driver.Value) bool
+	columns() []string
+}
+
+type andExpr []expr
+type orExpr []expr
+type notExpr struct{ e expr }
+
+type cmpExpr struct {
+	left, right operand
+	op          string
+}
+
+type inExpr struct {
+	left   operand
+	values []operand
+	not    bool
+}
+
+type nullExpr struct {
+	left operand
+	not  bool
+}
+
+type likeExpr struct {
+	left, pattern operand
+	not, fold     bool
+}
+
+func (e andExpr) match(row map[string]driver.Value) bool {
+	for _, c := range e {
+		if !c.match(row) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e orExpr) match(row map[string]driver.Value) bool {
+	for _, c := range e {
+		if c.match(row) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e notExpr) match(row map[string]driver.Value) bool {
+	return !e.e.match(row)
+}
+
+func (e cmpExpr) match(row map[string]driver.Value) bool {
+	c, ok := compare(e.left.eval(row), e.right.eval(row))
+	if !ok {
+		return false
+	}
+	switch e.op {
+	case "=":
+		return c == 0
+	case "<>", "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func (e inExpr) match(row map[string]driver.Value) bool {
+	v := e.left.eval(row)
+	if v == nil {
+		return false
+	}
+	for _, o := range e.values {
+		if c, ok := compare(v, o.eval(row)); ok && c == 0 {
+			return !e.not
+		}
+	}
+	return e.not
+}
+
+func (e nullExpr) match(row map[string]driver.Value) bool {
+	return (e.left.eval(row) == nil) != e.not
+}
+
+func (e likeExpr) match(row map[string]driver.Value) bool {
+	s, ok1 := text(e.left.eval(row))
+	p, ok2 := text(e.pattern.eval(row))
+	if !ok1 || !ok2 {
+		return false
+	}
+	if e.fold {
+		s, p = strings.ToLower(s), strings.ToLower(p)
+	}
+	return like(s, p) != e.not
+}
+
+func (e andExpr) columns() []string  { return exprColumns(e...) }
+func (e orExpr) columns() []string   { return exprColumns(e...) }
+func (e notExpr) columns() []string  { return e.e.columns() }
+func (e cmpExpr) columns() []string  { return operandColumns(e.left, e.right) }
+func (e nullExpr) columns() []string { return operandColumns(e.left) }
+func (e likeExpr) columns() []string { return operandColumns(e.left, e.pattern) }
+func (e inExpr) columns() []string {
+	return operandColumns(append([]operand{e.left}, e.values...)...)
+}
+
+func exprColumns(exprs ...expr) []string {
+	var cols []string
+	for _, e := range exprs {
+		cols = append(cols, e.columns()...)
+	}
+	return cols
+}
+
+func operandColumns(ops ...operand) []string {
+	var cols []string
+	for _, o := range ops {
+		if o.column != "" {
+			cols = append(cols, o.column)
+		}
+		cols = append(cols, operandColumns(o.args...)...)
+	}
+	return cols
+}
+
+// compare returns -1, 0, or 1 comparing a to b, or false if they are NULL or
+// of incomparable types.
+func compare(a, b driver.Value) (int, bool) {
+	if a == nil || b == nil {
+		return 0, false
+	}
+	if ai, ok := a.(int64); ok {
+		if bi, ok := b.(int64); ok {
+			return cmp3(ai < bi, ai > bi), true
+		}
+	}
+	if af, ok := number(a); ok {
+		if bf, ok := number(b); ok {
+			return cmp3(af < bf, af > bf), true
+		}
+		return 0, false
+	}
+	if as, ok := text(a); ok {
+		if bs, ok := text(b); ok {
+			return strings.Compare(as, bs), true
+		}
+		return 0, false
+	}
+	if at, ok := a.(time.Time); ok {
+		if bt, ok := b.(time.Time); ok {
+			return cmp3(at.Before(bt), at.After(bt)), true
+		}
+	}
+	return 0, false
+}
+
+func cmp3(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func number(v driver.Value) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func text(v driver.Value) (string, bool) {
+	switch s := v.(type) {
+	case string:
+		return s, true
+	case []byte:
+		return string(s), true
+	}
+	return "", false
+}
+
+// like matches s against a LIKE pattern with % and _ wildcards.
+func like(s, p string) bool {
+	for len(p) > 0 {
+		switch p[0] {
+		case '%':
+			for i := 0; i <= len(s); i++ {
+				if like(s[i:], p[1:]) {
+					return true
+				}
+			}
+			return false
+		case '_':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || s[0] != p[0] {
+				return false
+			}
+		}
+		s, p = s[1:], p[1:]
+	}
+	return len(s) == 0
+}
+
+// parse parses the given statement, binding ? placeholders to args in order.
+func parse(q string, args []driver.Value) (*parsed, error) {
+	toks, err := tokenize(q)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, args: args}
+
+	var s *parsed
+	switch {
+	case p.keyword("SELECT"):
+		s, err = p.parseSelect()
+	case p.keyword("INSERT"):
+		s, err = p.parseInsert()
+	case p.keyword("UPDATE"):
+		s, err = p.parseUpdate()
+	case p.keyword("DELETE"):
+		s, err = p.parseDelete()
+	default:
+		return nil, fmt.Errorf("fake: unsupported statement: %s", q)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fake: %w in %q", err, q)
+	}
+	if p.peek().kind != eofTok {
+		return nil, fmt.Errorf("fake: unexpected %q in %q", p.peek().text, q)
+	}
+	if p.arg != len(args) {
+		return nil, fmt.Errorf("fake: %d args for %d placeholders in %q", len(args), p.arg, q)
+	}
+	return s, nil
+}
+
+type parser struct {
+	toks []token
+	pos  int
+	args []driver.Value
+	arg  int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != eofTok {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the given keyword.
+func (p *parser) keyword(kw string) bool {
+	if t := p.peek(); t.kind == identTok && !t.quoted && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// symbol consumes the next token if it is the given symbol.
+func (p *parser) symbol(sym string) bool {
+	if t := p.peek(); t.kind == symbolTok && t.text == sym {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if !p.keyword(kw) {
+		return fmt.Errorf("expected %s, got %q", kw, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) expectSymbol(sym string) error {
+	if !p.symbol(sym) {
+		return fmt.Errorf("expected %q, got %q", sym, p.peek().text)
+	}
+	return nil
+}
+
+// ident parses an identifier, removing any table prefix.
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind != identTok {
+		return "", fmt.Errorf("expected identifier, got %q", t.text)
+	}
+	if i := strings.LastIndexByte(t.text, '.'); i >= 0 && !t.quoted {
+		return t.text[i+1:], nil
+	}
+	return t.text, nil
+}
+
+func (p *parser) identList() ([]string, error) {
+	var names []string
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.symbol(",") {
+			return names, nil
+		}
+	}
+}
+
+// operand parses a column, placeholder, or literal, with any arithmetic.
+func (p *parser) operand() (operand, error) {
+	return p.arithmetic(p.product, '+', '-')
+}
+
+func (p *parser) product() (operand, error) {
+	return p.arithmetic(p.primary, '*', '/')
+}
+
+// arithmetic parses operands joined by the given operators, evaluated left to
+// right.
+func (p *parser) arithmetic(next func() (operand, error), ops ...byte) (operand, error) {
+	left, err := next()
+	if err != nil {
+		return left, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != symbolTok || len(t.text) != 1 || (t.text[0] != ops[0] && t.text[0] != ops[1]) {
+			return left, nil
+		}
+		p.next()
+		right, err := next()
+		if err != nil {
+			return left, err
+		}
+		left = operand{op: t.text[0], args: []operand{left, right}}
+	}
+}
+
+// primary parses a column, placeholder, or literal.
+func (p *parser) primary() (operand, error) {
+	t := p.next()
+	switch t.kind {
+	case paramTok:
+		if p.arg >= len(p.args) {
+			return operand{}, fmt.Errorf("missing arg %d", p.arg+1)
+		}
+		p.arg++
+		return operand{value: p.args[p.arg-1]}, nil
+	case numberTok:
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return operand{value: i}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		return operand{value: f}, err
+	case stringTok:
+		return operand{value: t.text}, nil
+	case identTok:
+		switch {
+		case t.quoted:
+		case strings.EqualFold(t.text, "NULL"):
+			return operand{}, nil
+		case strings.EqualFold(t.text, "TRUE"):
+			return operand{value: true}, nil
+		case strings.EqualFold(t.text, "FALSE"):
+			return operand{value: false}, nil
+		}
+		p.pos--
+		name, err := p.ident()
+		return operand{column: name}, err
+	case symbolTok:
+		if t.text == "-" && p.peek().kind == numberTok {
+			o, err := p.primary()
+			switch v := o.value.(type) {
+			case int64:
+				o.value = -v
+			case float64:
+				o.value = -v
+			}
+			return o, err
+		}
+	}
+	return operand{}, fmt.Errorf("unexpected %q", t.text)
+}
+
+func (p *parser) operandList() ([]operand, error) {
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	var ops []operand
+	for {
+		o, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, o)
+		if p.symbol(")") {
+			return ops, nil
+		}
+		if err := p.expectSymbol(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseSelect() (*parsed, error) {
+	s := &parsed{kind: selectStmt, limit: -1}
+	for {
+		item, err := p.selectItem()
+		if err != nil {
+			return nil, err
+		}
+		s.items = append(s.items, item)
+		if !p.symbol(",") {
+			break
+		}
+	}
+
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if s.where, err = p.where(); err != nil {
+		return nil, err
+	}
+
+	if p.keyword("ORDER") {
+		if err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			col, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			o := order{column: col}
+			if p.keyword("DESC") {
+				o.desc = true
+			} else {
+				p.keyword("ASC")
+			}
+			s.orderBy = append(s.orderBy, o)
+			if !p.symbol(",") {
+				break
+			}
+		}
+	}
+
+	if p.keyword("LIMIT") {
+		if s.limit, err = p.count(); err != nil {
+			return nil, err
+		}
+	}
+	if p.keyword("OFFSET") {
+		if s.offset, err = p.count(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) selectItem() (selectItem, error) {
+	if p.symbol("*") {
+		return selectItem{star: true}, nil
+	}
+	if !p.keyword("COUNT") {
+		col, err := p.ident()
+		return selectItem{column: col}, err
+	}
+
+	item := selectItem{count: true}
+	for _, sym := range []string{"(", "*", ")"} {
+		if err := p.expectSymbol(sym); err != nil {
+			return item, err
+		}
+	}
+	if t := p.peek(); t.kind == symbolTok && isComparison(t.text) {
+		p.next()
+		item.op = t.text
+		var err error
+		item.val, err = p.operand()
+		return item, err
+	}
+	return item, nil
+}
+
+// count parses a LIMIT or OFFSET value.
+func (p *parser) count() (int64, error) {
+	o, err := p.operand()
+	if err != nil {
+		return 0, err
+	}
+	n, ok := o.value.(int64)
+	if !ok || o.column != "" || n < 0 {
+		return 0, fmt.Errorf("invalid count %v", o.value)
+	}
+	return n, nil
+}
+
+func (p *parser) parseInsert() (*parsed, error) {
+	s := &parsed{kind: insertStmt}
+	if err := p.expectKeyword("INTO"); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol("("); err != nil {
+		return nil, err
+	}
+	if s.columns, err = p.identList(); err != nil {
+		return nil, err
+	}
+	if err := p.expectSymbol(")"); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("VALUES"); err != nil {
+		return nil, err
+	}
+	for {
+		vals, err := p.operandList()
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) != len(s.columns) {
+			return nil, fmt.Errorf("%d values for %d columns", len(vals), len(s.columns))
+		}
+		s.values = append(s.values, vals)
+		if !p.symbol(",") {
+			return s, nil
+		}
+	}
+}
+
+func (p *parser) parseUpdate() (*parsed, error) {
+	s := &parsed{kind: updateStmt, values: make([][]operand, 1)}
+	var err error
+	if s.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("SET"); err != nil {
+		return nil, err
+	}
+	for {
+		col, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectSymbol("="); err != nil {
+			return nil, err
+		}
+		val, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		s.columns = append(s.columns, col)
+		s.values[0] = append(s.values[0], val)
+		if !p.symbol(",") {
+			break
+		}
+	}
+	s.where, err = p.where()
+	return s, err
+}
+
+func (p *parser) parseDelete() (*parsed, error) {
+	s := &parsed{kind: deleteStmt}
+	if err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	var err error
+	if s.table, err = p.ident(); err != nil {
+		return nil, err
+	}
+	s.where, err = p.where()
+	return s, err
+}
+
+// where parses an optional WHERE clause.
+func (p *parser) where() (expr, error) {
+	if !p.keyword("WHERE") {
+		return nil, nil
+	}
+	return p.or()
+}
+
+func (p *parser) or() (expr, error) {
+	e, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	ors := orExpr{e}
+	for p.keyword("OR") {
+		e, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		ors = append(ors, e)
+	}
+	if len(ors) == 1 {
+		return ors[0], nil
+	}
+	return ors, nil
+}
+
+func (p *parser) and() (expr, error) {
+	e, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	ands := andExpr{e}
+	for p.keyword("AND") {
+		e, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		ands = append(ands, e)
+	}
+	if len(ands) == 1 {
+		return ands[0], nil
+	}
+	return ands, nil
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.keyword("NOT") {
+		e, err := p.unary()
+		return notExpr{e}, err
+	}
+	if p.symbol("(") {
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expectSymbol(")")
+	}
+	return p.predicate()
+}
+
+func (p *parser) predicate() (expr, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind == symbolTok && isComparison(t.text) {
+		p.next()
+		right, err := p.operand()
+		return cmpExpr{left: left, op: t.text, right: right}, err
+	}
+
+	if p.keyword("IS") {
+		not := p.keyword("NOT")
+		if err := p.expectKeyword("NULL"); err != nil {
+			return nil, err
+		}
+		return nullExpr{left: left, not: not}, nil
+	}
+
+	not := p.keyword("NOT")
+	switch {
+	case p.keyword("IN"):
+		vals, err := p.operandList()
+		return inExpr{left: left, values: vals, not: not}, err
+	case p.keyword("LIKE"):
+		pat, err := p.operand()
+		return likeExpr{left: left, pattern: pat, not: not}, err
+	case p.keyword("ILIKE"):
+		pat, err := p.operand()
+		return likeExpr{left: left, pattern: pat, not: not, fold: true}, err
+	}
+	return nil, fmt.Errorf("unexpected %q", p.peek().text)
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+		return true
+	}
+	return false
+}
+
+type tokenKind int
+
+const (
+	eofTok tokenKind = iota
+	identTok
+	numberTok
+	stringTok
+	paramTok
+	symbolTok
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	quoted bool
+}
+
+// tokenize splits the statement into tokens, skipping comments.
+func tokenize(q string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(q); {
+		c := q[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ';':
+			i++
+		case strings.HasPrefix(q[i:], "/*"):
+			end := strings.Index(q[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("fake: unterminated comment in %q", q)
+			}
+			i += end + 4
+		case strings.HasPrefix(q[i:], "--"):
+			end := strings.IndexByte(q[i:], '\n')
+			if end < 0 {
+				end = len(q) - i
+			}
+			i += end
+		case c == '?':
+			toks = append(toks, token{kind: paramTok, text: "?"})
+			i++
+		case c == '\'' || c == '"' || c == '`':
+			s, n, err := quoted(q[i:])
+			if err != nil {
+				return nil, err
+			}
+			if c == '\'' {
+				toks = append(toks, token{kind: stringTok, text: s})
+			} else {
+				toks = append(toks, token{kind: identTok, text: s, quoted: true})
+			}
+			i += n
+		case isDigit(c):
+			j := i
+			for j < len(q) && (isDigit(q[j]) || q[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{kind: numberTok, text: q[i:j]})
+			i = j
+		case isIdent(c):
+			j := i
+			for j < len(q) && (isIdent(q[j]) || isDigit(q[j]) || q[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{kind: identTok, text: q[i:j]})
+			i = j
+		default:
+			sym := q[i : i+1]
+			if i+1 < len(q) {
+				switch two := q[i : i+2]; two {
+				case "<>", "!=", "<=", ">=":
+					sym = two
+				}
+			}
+			toks = append(toks, token{kind: symbolTok, text: sym})
+			i += len(sym)
+		}
+	}
+	return append(toks, token{kind: eofTok}), nil
+}
+
+// quoted returns the unquoted contents of the quoted string at the start of s,
+// and its quoted length. Doubled quotes are escapes.
+func quoted(s string) (string, int, error) {
+	quote := s[0]
+	var b bytes.Buffer
+	for i := 1; i < len(s); i++ {
+		if s[i] != quote {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		return b.String(), i + 1, nil
+	}
+	return "", 0, fmt.Errorf("fake: unterminated quote in %q", s)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,168 @@
+package schemabletest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/schemabletest/fake"
+)
+
+func FakeTests(t *testing.T) {
+	t.Run("fake.Client", func(t *testing.T) {
+		c := fake.New()
+		fake.Register(c, ComicTitles)
+		ctx := schemable.WithClient(context.Background(), c)
+		if d := c.Dialect(); d != schemable.SQLite {
+			t.Errorf("unexpected dialect %v", d)
+		}
+
+		RecorderTests(t, ctx)
+		SchemerTests(t, ctx)
+
+		t.Run("Seed()", func(t *testing.T) {
+			c := fake.New()
+			seeded := []*ComicTitle{
+				{ID2: 380, Name: "seed a", Volume: 3},
+				{ID2: 380, Name: "seed b", Volume: 1},
+				{ID2: 381, Name: "seed c", Volume: 2},
+			}
+			if err := fake.Seed(c, ComicTitles, seeded...); err != nil {
+				t.Fatal(err)
+			}
+			for i, tgt := range seeded {
+				if tgt.ID != int64(i+1) {
+					t.Errorf("unexpected ID for target %d: %d", i, tgt.ID)
+				}
+			}
+
+			all, err := fake.All(c, ComicTitles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 3 || *all[1] != *seeded[1] {
+				t.Errorf("unexpected targets: %+v", all)
+			}
+
+			ctx := schemable.WithClient(context.Background(), c)
+			recs, err := ComicTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q.Where(sq.And{
+					sq.Eq{"id_two": []int64{380, 381}},
+					sq.Or{sq.Gt{"volume": 1}, sq.Like{"name": "%b"}},
+				}).OrderBy("volume DESC").Limit(2).Offset(1)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != 2 || recs[0].Target.Name != "seed c" || recs[1].Target.Name != "seed b" {
+				t.Errorf("unexpected records: %+v", schemable.Targets(recs))
+			}
+
+			n, err := ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where("volume < ?", 3)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if affected, _ := n.RowsAffected(); affected != 2 {
+				t.Errorf("unexpected rows affected: %d", affected)
+			}
+
+			rows := c.Rows("comic_titles")
+			if len(rows) != 1 || rows[0]["name"] != "seed a" || rows[0]["volume"] != int64(3) {
+				t.Errorf("unexpected rows: %+v", rows)
+			}
+
+			c.Truncate()
+			if rows := c.Rows("comic_titles"); len(rows) != 0 {
+				t.Errorf("unexpected rows: %+v", rows)
+			}
+		})
+
+		t.Run("SeedRows()", func(t *testing.T) {
+			c := fake.New()
+			err := c.SeedRows("tags",
+				map[string]any{"id": 1, "name": "a"},
+				map[string]any{"id": 2, "name": "b"},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !c.HasTable("tags") {
+				t.Error("missing table")
+			}
+
+			var name string
+			if err := c.QueryRow(context.Background(), "SELECT name FROM tags WHERE id = ?", 2).Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			if name != "b" {
+				t.Errorf("unexpected name: %q", name)
+			}
+		})
+
+		t.Run("errors", func(t *testing.T) {
+			c := fake.New()
+			ctx := schemable.WithClient(context.Background(), c)
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 380, Name: "errors"})
+
+			if err := rec.Insert(ctx); err == nil || !strings.Contains(err.Error(), "no such table") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+
+			fake.Register(c, ComicTitles)
+			if err := fake.Seed(c, ComicTitles, rec.Target); err != nil {
+				t.Fatal(err)
+			}
+			err := c.SeedRows("comic_titles", map[string]any{"id": rec.Target.ID, "id_two": 380})
+			if err == nil || !strings.Contains(err.Error(), "UNIQUE") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+
+			err = rec.LoadWhere(ctx, "missing_column = ?", 1)
+			if err == nil || !strings.Contains(err.Error(), "no column named missing_column") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+
+			if _, err := c.Exec(ctx, "CREATE TABLE other (id INTEGER)"); err == nil || !strings.Contains(err.Error(), "unsupported") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("transactions", func(t *testing.T) {
+			c := fake.New()
+			ctx := schemable.WithClient(context.Background(), c)
+			if err := fake.Seed(c, ComicTitles, &ComicTitle{ID2: 380, Name: "txn", Volume: 1}); err != nil {
+				t.Fatal(err)
+			}
+
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec := ComicTitles.Record(&ComicTitle{ID2: 380, Name: "rolled back"})
+			if err := rec.Insert(tctx); err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, tctx, rec)
+			if err := tc.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+			refuteExists(t, ctx, rec)
+
+			tctx, tc, err = schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rec.Insert(tctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, ctx, rec)
+		})
+	})
+}
@@ -0,0 +1,123 @@
+package schemabletest
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/gen"
+)
+
+func GenTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("gen.Structs()", func(t *testing.T) {
+		t.Run("naming and types", func(t *testing.T) {
+			tables := []*schemable.TableSchema{
+				{Table: "comic_titles", Columns: []schemable.ColumnSchema{
+					{Name: "id", Type: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+					{Name: "id_two", Type: "INTEGER", PrimaryKey: true},
+					{Name: "name", Type: "VARCHAR(64)"},
+					{Name: "volume", Type: "int", Nullable: true},
+					{Name: "cover_url", Type: "TEXT", Nullable: true},
+					{Name: "published", Type: "DATETIME", Nullable: true},
+					{Name: "cover", Type: "BLOB", Nullable: true},
+					{Name: "price", Type: "DECIMAL(10,2)"},
+					{Name: "uuid", Type: "CHAR(36)"},
+				}},
+				{Table: "series", Columns: []schemable.ColumnSchema{
+					{Name: "code", Type: "TEXT", PrimaryKey: true},
+					{Name: "active", Type: "BOOLEAN"},
+				}},
+			}
+
+			var buf bytes.Buffer
+			err := gen.Structs(&buf, tables, gen.Options{
+				Package:    "comics",
+				Singular:   true,
+				FieldNames: map[string]string{"comic_titles.id_two": "SecondID"},
+				Types: map[string]string{
+					"uuid":    "github.com/google/uuid.UUID",
+					"DECIMAL": "string",
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := "// Code generated by schemable-gen. DO NOT EDIT.\n\n" +
+				"package comics\n\n" +
+				"import (\n" +
+				"\t\"time\"\n\n" +
+				"\t\"github.com/google/uuid\"\n" +
+				"\t\"github.com/refractionist/schemable\"\n" +
+				")\n\n" +
+				"// ComicTitle is a row of the comic_titles table.\n" +
+				"type ComicTitle struct {\n" +
+				"\tID        int64      `db:\"id, PRIMARY KEY, AUTO INCREMENT\"`\n" +
+				"\tSecondID  int64      `db:\"id_two, PRIMARY KEY\"`\n" +
+				"\tName      string     `db:\"name\"`\n" +
+				"\tVolume    *int64     `db:\"volume\"`\n" +
+				"\tCoverURL  *string    `db:\"cover_url\"`\n" +
+				"\tPublished *time.Time `db:\"published\"`\n" +
+				"\tCover     []byte     `db:\"cover\"`\n" +
+				"\tPrice     string     `db:\"price\"`\n" +
+				"\tUUID      uuid.UUID  `db:\"uuid\"`\n" +
+				"}\n\n" +
+				"// ComicTitles binds ComicTitle to the comic_titles table.\n" +
+				"var ComicTitles = schemable.Bind[ComicTitle](\"comic_titles\")\n\n" +
+				"// Series is a row of the series table.\n" +
+				"type Series struct {\n" +
+				"\tCode   string `db:\"code, PRIMARY KEY\"`\n" +
+				"\tActive bool   `db:\"active\"`\n" +
+				"}\n\n" +
+				"// SeriesTable binds Series to the series table.\n" +
+				"var SeriesTable = schemable.Bind[Series](\"series\")\n"
+			if got := buf.String(); got != want {
+				t.Errorf("unexpected source:\n%s", got)
+			}
+		})
+
+		if dc.Dialect() == schemable.UnknownDialect {
+			return
+		}
+
+		t.Run("from database", func(t *testing.T) {
+			ctx := schemable.WithClient(context.Background(), dc)
+			tables, err := schemable.Tables(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var found bool
+			for _, name := range tables {
+				found = found || name == "comic_titles"
+			}
+			if !found {
+				t.Fatalf("comic_titles not in tables: %v", tables)
+			}
+
+			ts, err := schemable.Introspect(ctx, "comic_titles")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ts.Column("id").AutoIncrement || ts.Column("id_two").AutoIncrement {
+				t.Errorf("unexpected auto increment columns: %+v", ts.Columns)
+			}
+
+			var buf bytes.Buffer
+			if err := gen.Structs(&buf, []*schemable.TableSchema{ts}, gen.Options{Singular: true}); err != nil {
+				t.Fatal(err)
+			}
+			for _, line := range []string{
+				"type ComicTitle struct {",
+				"`db:\"id, PRIMARY KEY, AUTO INCREMENT\"`",
+				"IDTwo  int64  `db:\"id_two\"`",
+				"Name   string `db:\"name\"`",
+			} {
+				if !strings.Contains(buf.String(), line) {
+					t.Errorf("missing %q in source:\n%s", line, buf.String())
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,194 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func IdentityTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("IdentityMap", func(t *testing.T) {
+		ctx := schemable.WithClient(context.Background(), dc)
+		rec := ComicTitles.Record(&ComicTitle{ID2: 480, Name: "identity", Volume: 1})
+		if err := rec.Insert(ctx); err != nil {
+			t.Fatal(err)
+		}
+		defer ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+			return q.Where(titleID2.Eq(480))
+		})
+
+		t.Run("without a map", func(t *testing.T) {
+			if schemable.IdentityMapFrom(ctx) != nil {
+				t.Fatal("unexpected identity map")
+			}
+			a, err := ComicTitles.Find(ctx, rec.Target.ID, 480)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ComicTitles.Find(ctx, rec.Target.ID, 480)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Target == b.Target || a.Target.Name != "identity" {
+				t.Errorf("unexpected targets: %+v %+v", a.Target, b.Target)
+			}
+		})
+
+		t.Run("Find()", func(t *testing.T) {
+			if _, err := ComicTitles.Find(ctx, rec.Target.ID); err == nil {
+				t.Error("expected error for missing key")
+			}
+			if _, err := ComicTitles.Find(ctx, rec.Target.ID, "480"); err == nil {
+				t.Error("expected error for invalid key")
+			}
+			if _, err := ComicTitles.Find(ctx, rec.Target.ID, 480.5); err == nil {
+				t.Error("expected error for float key")
+			}
+			if _, err := ComicTitles.Find(ctx, rec.Target.ID, uint64(math.MaxUint64)); err == nil {
+				t.Error("expected error for overflowing key")
+			}
+			if r, err := ComicTitles.Find(ctx, uint(rec.Target.ID), int16(480)); err != nil || r.Target.ID2 != 480 {
+				t.Errorf("unexpected result: %+v, %+v", r, err)
+			}
+			if _, err := ComicTitles.Find(ctx, rec.Target.ID, 481); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("same targets", func(t *testing.T) {
+			ictx := schemable.WithIdentityMap(ctx)
+			m := schemable.IdentityMapFrom(ictx)
+			found, err := ComicTitles.Find(ictx, int(rec.Target.ID), 480)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, err := ComicTitles.First(ictx, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q.Where(titleID2.Eq(480))
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded := ComicTitles.Record(&ComicTitle{ID: rec.Target.ID, ID2: 480})
+			if err := loaded.Load(ictx); err != nil {
+				t.Fatal(err)
+			}
+			if found.Target != first.Target || found.Target != loaded.Target || m.Len() != 1 {
+				t.Errorf("unexpected targets: %p %p %p", found.Target, first.Target, loaded.Target)
+			}
+
+			caller := ComicTitle{ID: rec.Target.ID, ID2: 480}
+			if err := ComicTitles.Record(&caller).Load(ictx); err != nil {
+				t.Fatal(err)
+			}
+			if caller.Name != "identity" || caller.Volume != 1 {
+				t.Errorf("cached target not copied: %+v", caller)
+			}
+
+			found.Target.Name = "identity 2"
+			if vals := first.UpdatedValues(); len(vals) != 1 {
+				t.Errorf("unexpected updated values: %+v", vals)
+			}
+			if err := found.Update(ictx); err != nil {
+				t.Fatal(err)
+			}
+			if m.Len() != 0 {
+				t.Errorf("updated target still cached")
+			}
+			again, err := ComicTitles.Find(ictx, rec.Target.ID, 480)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again.Target == found.Target || again.Target.Name != "identity 2" {
+				t.Errorf("unexpected target: %+v", again.Target)
+			}
+
+			other := schemable.WithIdentityMap(ctx)
+			if r, err := ComicTitles.Find(other, rec.Target.ID, 480); err != nil || r.Target == again.Target {
+				t.Errorf("unexpected target from other map: %+v", err)
+			}
+
+			if _, err := ComicTitles.DeleteWhere(ictx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where(titleID2.Eq(-1))
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if m.Len() != 0 {
+				t.Errorf("DeleteWhere() kept cached targets")
+			}
+
+			if _, err := ComicTitles.Find(ictx, rec.Target.ID, 480); err != nil {
+				t.Fatal(err)
+			}
+			m.Clear()
+			if m.Len() != 0 {
+				t.Errorf("Clear() kept cached targets")
+			}
+		})
+
+		t.Run("Delete()", func(t *testing.T) {
+			ictx := schemable.WithIdentityMap(ctx)
+			del := ComicTitles.Record(&ComicTitle{ID2: 480, Name: "deleted", Volume: 2})
+			if err := del.Insert(ictx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ComicTitles.Find(ictx, del.Target.ID, 480); err != nil {
+				t.Fatal(err)
+			}
+			if err := del.Delete(ictx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ComicTitles.Find(ictx, del.Target.ID, 480); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("transactions", func(t *testing.T) {
+			ictx := schemable.WithIdentityMap(ctx)
+			m := schemable.IdentityMapFrom(ictx)
+
+			tctx, tc, err := schemable.WithTransaction(ictx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rolledBack := false
+			tc.OnRollback(func() { rolledBack = true })
+			tc.OnCommit(func() { t.Error("unexpected commit") })
+			if _, err := ComicTitles.Find(tctx, rec.Target.ID, 480); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+			if !rolledBack || m.Len() != 0 {
+				t.Errorf("rollback kept %d targets", m.Len())
+			}
+			tc.Rollback()
+
+			tctx, tc, err = schemable.WithTransaction(ictx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			committed := false
+			tc.OnCommit(func() { committed = true })
+			found, err := ComicTitles.Find(tctx, rec.Target.ID, 480)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.Commit(); err != nil {
+				t.Fatal(err)
+			}
+			if !committed || m.Len() != 1 {
+				t.Errorf("commit dropped %d targets", 1-m.Len())
+			}
+			tc.OnCommit(func() { t.Error("hook added after commit") })
+			if r, err := ComicTitles.Find(ictx, rec.Target.ID, 480); err != nil || r.Target != found.Target {
+				t.Errorf("unexpected target: %+v", err)
+			}
+		})
+	})
+}
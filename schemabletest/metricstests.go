@@ -0,0 +1,126 @@
+package schemabletest
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func MetricsTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Metrics", func(t *testing.T) {
+		m := schemable.NewMetrics(time.Nanosecond, time.Minute)
+		c := schemable.FromDB(dc.DB())
+		c.SetMetrics(m)
+		ctx := schemable.WithClient(context.Background(), c)
+
+		rec := ComicTitles.Record(&ComicTitle{
+			ID2:    300,
+			Name:   "metrics",
+			Volume: 300,
+		})
+		if err := rec.Insert(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := rec.Load(ctx); err != nil {
+			t.Fatal(err)
+		}
+		assertExists(t, ctx, rec)
+		if err := rec.Delete(ctx); err != nil {
+			t.Fatal(err)
+		}
+		ComicTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+			return q.Where("missing_column = 1")
+		})
+
+		tc, err := c.Begin(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		refuteExists(t, schemable.WithClient(ctx, tc), rec)
+		tc.Rollback()
+
+		t.Run("Snapshot()", func(t *testing.T) {
+			stats := m.Snapshot()
+			if len(stats) != 3 {
+				t.Fatalf("unexpected stats: %+v", stats)
+			}
+
+			del, ins, sel := stats[0], stats[1], stats[2]
+			if del.Op != schemable.OpDelete || del.Table != "comic_titles" || del.Count != 1 || del.Errors != 0 {
+				t.Errorf("unexpected delete stats: %+v", del)
+			}
+			if ins.Op != schemable.OpInsert || ins.Count != 1 || ins.Errors != 0 {
+				t.Errorf("unexpected insert stats: %+v", ins)
+			}
+			if sel.Op != schemable.OpSelect || sel.Count != 4 || sel.Errors != 1 {
+				t.Errorf("unexpected select stats: %+v", sel)
+			}
+
+			if len(sel.Buckets) != 2 {
+				t.Fatalf("unexpected buckets: %+v", sel.Buckets)
+			}
+			if b := sel.Buckets[0]; b.UpperBound != time.Nanosecond || b.Count != 0 {
+				t.Errorf("unexpected first bucket: %+v", b)
+			}
+			if b := sel.Buckets[1]; b.UpperBound != time.Minute || b.Count != 4 {
+				t.Errorf("unexpected second bucket: %+v", b)
+			}
+			if sel.Sum <= 0 {
+				t.Errorf("unexpected sum: %s", sel.Sum)
+			}
+		})
+
+		t.Run("LogQuery()", func(t *testing.T) {
+			m := schemable.NewMetrics()
+			c := schemable.FromDB(dc.DB())
+			c.SetMetrics(m)
+			c.LogQuery(ctx, "UPDATE comic_titles SET name = ?", nil)
+
+			stats := m.Snapshot()
+			if len(stats) != 1 || stats[0].Op != schemable.OpUpdate || stats[0].Count != 1 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+		})
+
+		t.Run("ServeHTTP()", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+				t.Errorf("unexpected content type: %q", ct)
+			}
+
+			body := w.Body.String()
+			for _, line := range []string{
+				"# TYPE schemable_queries_total counter",
+				`schemable_queries_total{op="select",table="comic_titles"} 4`,
+				`schemable_query_errors_total{op="select",table="comic_titles"} 1`,
+				"# TYPE schemable_query_duration_seconds histogram",
+				`schemable_query_duration_seconds_bucket{op="insert",table="comic_titles",le="1e-09"} 0`,
+				`schemable_query_duration_seconds_bucket{op="insert",table="comic_titles",le="60"} 1`,
+				`schemable_query_duration_seconds_bucket{op="insert",table="comic_titles",le="+Inf"} 1`,
+				`schemable_query_duration_seconds_count{op="delete",table="comic_titles"} 1`,
+			} {
+				if !strings.Contains(body, line+"\n") {
+					t.Errorf("missing line: %s", line)
+				}
+			}
+
+			if t.Failed() {
+				t.Log(body)
+			}
+		})
+
+		t.Run("Reset()", func(t *testing.T) {
+			m.Reset()
+			if stats := m.Snapshot(); len(stats) != 0 {
+				t.Errorf("unexpected stats: %+v", stats)
+			}
+		})
+	})
+}
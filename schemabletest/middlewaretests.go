@@ -0,0 +1,159 @@
+package schemabletest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/refractionist/schemable"
+)
+
+func MiddlewareTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Middleware", func(t *testing.T) {
+		c := schemable.FromDB(dc.DB())
+		ctx := schemable.WithClient(context.Background(), c)
+
+		var calls []string
+		c.Use(func(next schemable.Handler) schemable.Handler {
+			return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+				calls = append(calls, "outer")
+				return next(ctx, stmt)
+			}
+		}, func(next schemable.Handler) schemable.Handler {
+			return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+				calls = append(calls, "inner")
+				return next(ctx, stmt)
+			}
+		})
+
+		t.Run("order", func(t *testing.T) {
+			calls = nil
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			assertExists(t, ctx, rec)
+			if strings.Join(calls, ",") != "outer,inner" {
+				t.Errorf("unexpected calls: %+v", calls)
+			}
+		})
+
+		t.Run("statement kinds", func(t *testing.T) {
+			var kinds []schemable.StatementKind
+			c := schemable.FromDB(dc.DB())
+			c.Use(func(next schemable.Handler) schemable.Handler {
+				return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+					kinds = append(kinds, stmt.Kind)
+					return next(ctx, stmt)
+				}
+			})
+			kctx := schemable.WithClient(context.Background(), c)
+
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    270,
+				Name:   "middleware",
+				Volume: 270,
+			})
+			if err := rec.Insert(kctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := rec.Load(kctx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ComicTitles.List(kctx, 1, 0); err != nil {
+				t.Fatal(err)
+			}
+			if err := rec.Delete(kctx); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := []schemable.StatementKind{
+				schemable.ExecStatement,
+				schemable.QueryRowStatement,
+				schemable.QueryStatement,
+				schemable.ExecStatement,
+			}
+			if len(kinds) != len(expected) {
+				t.Fatalf("unexpected kinds: %+v", kinds)
+			}
+			for i, k := range expected {
+				if kinds[i] != k {
+					t.Errorf("kind %d: expected %d, got %d", i, k, kinds[i])
+				}
+			}
+		})
+
+		t.Run("blocking", func(t *testing.T) {
+			errBlocked := errors.New("blocked")
+			c := schemable.FromDB(dc.DB())
+			c.Use(func(next schemable.Handler) schemable.Handler {
+				return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+					return schemable.Response{Err: errBlocked}
+				}
+			})
+			bctx := schemable.WithClient(context.Background(), c)
+
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			if err := rec.Load(bctx); err != errBlocked {
+				t.Errorf("unexpected Load() error: %+v", err)
+			}
+			if _, err := ComicTitles.List(bctx, 1, 0); err != errBlocked {
+				t.Errorf("unexpected List() error: %+v", err)
+			}
+			if err := rec.Delete(bctx); err != errBlocked {
+				t.Errorf("unexpected Delete() error: %+v", err)
+			}
+			assertExists(t, ctx, rec)
+		})
+
+		t.Run("rewriting", func(t *testing.T) {
+			c := schemable.FromDB(dc.DB())
+			c.Use(func(next schemable.Handler) schemable.Handler {
+				return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+					stmt.SQL = strings.Replace(stmt.SQL, "comic_titles.id_two = ?", "comic_titles.id_two = ? + 1", 1)
+					return next(ctx, stmt)
+				}
+			})
+			rctx := schemable.WithClient(context.Background(), c)
+
+			rec := ComicTitles.Record(&ComicTitle{ID: 2, ID2: 1})
+			if err := rec.Load(rctx); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.Name != "direct" {
+				recorderErr(t, rec)
+			}
+		})
+
+		t.Run("TxnClient", func(t *testing.T) {
+			tc, err := c.Begin(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			var txCalls int
+			tc.Use(func(next schemable.Handler) schemable.Handler {
+				return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+					txCalls++
+					return next(ctx, stmt)
+				}
+			})
+
+			calls = nil
+			tctx := schemable.WithClient(ctx, tc)
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			assertExists(t, tctx, rec)
+			if strings.Join(calls, ",") != "outer,inner" {
+				t.Errorf("unexpected inherited calls: %+v", calls)
+			}
+			if txCalls != 1 {
+				t.Errorf("unexpected transaction calls: %d", txCalls)
+			}
+
+			calls = nil
+			assertExists(t, ctx, rec)
+			if len(calls) != 2 {
+				t.Errorf("unexpected calls: %+v", calls)
+			}
+		})
+	})
+}
@@ -0,0 +1,395 @@
+package schemabletest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/migrate"
+)
+
+var migrationFiles = fstest.MapFS{
+	"migrations/0001_create_series.up.sql": {Data: []byte(`
+-- series of comics
+CREATE TABLE migrate_series (
+  id INTEGER NOT NULL,
+  name VARCHAR(64) NOT NULL
+);
+INSERT INTO migrate_series (id, name) VALUES (1, 'X-Men; Uncanny');
+`)},
+	"migrations/0001_create_series.down.sql": {Data: []byte("DROP TABLE migrate_series;")},
+	"migrations/0002_add_publisher.sql":      {Data: []byte("ALTER TABLE migrate_series ADD COLUMN publisher VARCHAR(64)")},
+	"migrations/README.md":                   {Data: []byte("not a migration")},
+}
+
+func MigrateTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("migrate", func(t *testing.T) {
+		t.Run("SplitStatements()", func(t *testing.T) {
+			stmts := migrate.SplitStatements(`
+-- leading comment
+INSERT INTO t VALUES ('a;b'); /* ; */ SELECT ";" ;
+-- trailing comment;
+`)
+			want := []string{
+				"-- leading comment\nINSERT INTO t VALUES ('a;b')",
+				`/* ; */ SELECT ";"`,
+			}
+			if !reflect.DeepEqual(stmts, want) {
+				t.Errorf("unexpected statements: %q", stmts)
+			}
+
+			stmts = migrate.SplitStatements(`
+CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;
+CREATE FUNCTION two() RETURNS int AS $body$ BEGIN RETURN 2; END; $body$ LANGUAGE plpgsql;
+SELECT $1; SELECT '$$'
+`)
+			want = []string{
+				"CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql",
+				"CREATE FUNCTION two() RETURNS int AS $body$ BEGIN RETURN 2; END; $body$ LANGUAGE plpgsql",
+				"SELECT $1",
+				"SELECT '$$'",
+			}
+			if !reflect.DeepEqual(stmts, want) {
+				t.Errorf("unexpected dollar quoted statements: %q", stmts)
+			}
+
+			stmts = migrate.SplitStatements(`
+CREATE TRIGGER stamp AFTER INSERT ON t BEGIN
+  UPDATE t SET n = CASE WHEN n > 0 THEN n ELSE 1 END WHERE id = NEW.id;
+  INSERT INTO log VALUES ('end;');
+END;
+CREATE PROCEDURE p() BEGIN IF 1 THEN SELECT 1; END IF; SELECT 2; END;
+INSERT INTO t VALUES ('it\'s; ok', "a\"; b"); SELECT 'a''b;c'
+`)
+			want = []string{
+				"CREATE TRIGGER stamp AFTER INSERT ON t BEGIN\n" +
+					"  UPDATE t SET n = CASE WHEN n > 0 THEN n ELSE 1 END WHERE id = NEW.id;\n" +
+					"  INSERT INTO log VALUES ('end;');\nEND",
+				"CREATE PROCEDURE p() BEGIN IF 1 THEN SELECT 1; END IF; SELECT 2; END",
+				`INSERT INTO t VALUES ('it\'s; ok', "a\"; b")`,
+				"SELECT 'a''b;c'",
+			}
+			if !reflect.DeepEqual(stmts, want) {
+				t.Errorf("unexpected trigger statements: %q", stmts)
+			}
+		})
+
+		t.Run("FromFS()", func(t *testing.T) {
+			ms, err := migrate.FromFS(migrationFiles, "migrations")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ms) != 2 {
+				t.Fatalf("unexpected migrations: %d", len(ms))
+			}
+			if ms[0].Version != 1 || ms[0].Name != "create_series" || ms[0].Up == nil || ms[0].Down == nil {
+				t.Errorf("unexpected migration: %+v", ms[0])
+			}
+			if ms[1].Version != 2 || ms[1].Name != "add_publisher" || ms[1].Up == nil || ms[1].Down != nil {
+				t.Errorf("unexpected migration: %+v", ms[1])
+			}
+
+			_, err = migrate.FromFS(fstest.MapFS{"m/create.sql": {}}, "m")
+			if err == nil || !strings.Contains(err.Error(), "no version") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+
+			_, err = migrate.FromFS(fstest.MapFS{
+				"m/1_a.sql":     {},
+				"m/01_a.up.sql": {},
+			}, "m")
+			if err == nil || !strings.Contains(err.Error(), "duplicate") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("unknown dialect", func(t *testing.T) {
+			u := schemable.FromDB(dc.DB())
+			u.SetDialect(schemable.UnknownDialect)
+			if err := migrate.New(u).Up(context.Background()); err == nil {
+				t.Error("expected error for unknown dialect")
+			}
+		})
+
+		if dc.Dialect() == schemable.UnknownDialect {
+			return
+		}
+
+		c := schemable.FromDB(dc.DB())
+		c.SetDialect(dc.Dialect())
+		events := &eventLog{}
+		c.SetEventLogger(events)
+		ctx := context.Background()
+
+		ms, err := migrate.FromFS(migrationFiles, "migrations")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var goRan int
+		m := migrate.New(c, ms...)
+		m.SetTable("test_migrations")
+		m.Add(&migrate.Migration{
+			Version: 3,
+			Name:    "go",
+			Up: func(ctx context.Context, c schemable.Client) error {
+				goRan++
+				if schemable.ClientFrom(ctx) != c {
+					t.Error("migration client not in ctx")
+				}
+				if _, ok := c.(*schemable.TxnClient); !ok && c.(*schemable.DBClient).Dialect() != schemable.MySQL {
+					t.Error("migration not in a transaction")
+				}
+				return migrate.Exec(ctx, c, "UPDATE migrate_series SET publisher = ?", "Marvel")
+			},
+			Down: func(ctx context.Context, c schemable.Client) error {
+				goRan--
+				return nil
+			},
+		})
+		defer c.Exec(ctx, "DROP TABLE test_migrations")
+		defer c.Exec(ctx, "DROP TABLE IF EXISTS migrate_series")
+
+		t.Run("Status() before Up()", func(t *testing.T) {
+			pending, err := m.Pending(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pending) != 3 {
+				t.Errorf("unexpected pending: %d", len(pending))
+			}
+		})
+
+		t.Run("UpTo()", func(t *testing.T) {
+			if err := m.UpTo(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			assertApplied(t, m, true, false, false)
+
+			var name string
+			if err := c.QueryRow(ctx, "SELECT name FROM migrate_series WHERE id = 1").Scan(&name); err != nil {
+				t.Fatal(err)
+			}
+			if name != "X-Men; Uncanny" {
+				t.Errorf("unexpected name: %q", name)
+			}
+		})
+
+		t.Run("Up()", func(t *testing.T) {
+			events.reset()
+			if err := m.Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+			assertApplied(t, m, true, true, true)
+			if goRan != 1 {
+				t.Errorf("go migration ran %d times", goRan)
+			}
+
+			var logged bool
+			for _, e := range events.events {
+				if strings.HasPrefix(e.SQL, "ALTER TABLE migrate_series") {
+					logged = true
+				}
+			}
+			if !logged {
+				t.Error("migration statement not logged")
+			}
+
+			events.reset()
+			if err := m.Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if goRan != 1 {
+				t.Errorf("go migration ran %d times", goRan)
+			}
+		})
+
+		t.Run("Status() with missing migration", func(t *testing.T) {
+			other := migrate.New(c)
+			other.SetTable("test_migrations")
+			statuses, err := other.Status(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statuses) != 3 || !statuses[0].Missing || statuses[0].Name != "create_series" || statuses[0].AppliedAt.IsZero() {
+				t.Errorf("unexpected statuses: %+v", statuses)
+			}
+		})
+
+		t.Run("Down()", func(t *testing.T) {
+			if err := m.Down(ctx); err != nil {
+				t.Fatal(err)
+			}
+			assertApplied(t, m, true, true, false)
+			if goRan != 0 {
+				t.Errorf("go migration ran %d times", goRan)
+			}
+
+			err := m.Down(ctx)
+			if !errors.Is(err, migrate.ErrNoDown) {
+				t.Errorf("unexpected error: %+v", err)
+			}
+			assertApplied(t, m, true, true, false)
+		})
+
+		t.Run("failed migration", func(t *testing.T) {
+			if dc.Dialect() == schemable.MySQL {
+				t.Skip("MySQL commits DDL implicitly")
+			}
+			failing := migrate.New(c)
+			failing.SetTable("test_migrations")
+			failing.Add(&migrate.Migration{
+				Version: 4,
+				Name:    "fails",
+				Up: func(ctx context.Context, c schemable.Client) error {
+					if err := migrate.Exec(ctx, c, "DELETE FROM migrate_series"); err != nil {
+						return err
+					}
+					return migrate.Exec(ctx, c, "SELECT * FROM missing_table")
+				},
+			})
+			err := failing.Up(ctx)
+			if err == nil || !strings.Contains(err.Error(), "up 4 fails") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+
+			var count int
+			if err := c.QueryRow(ctx, "SELECT COUNT(*) FROM migrate_series").Scan(&count); err != nil {
+				t.Fatal(err)
+			}
+			if count != 1 {
+				t.Errorf("migration not rolled back: %d rows", count)
+			}
+			pending, err := failing.Pending(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pending) != 1 {
+				t.Errorf("unexpected pending: %d", len(pending))
+			}
+		})
+
+		t.Run("DownTo()", func(t *testing.T) {
+			m2 := migrate.New(c, ms[0])
+			m2.SetTable("test_migrations")
+			m2.Add(&migrate.Migration{
+				Version: 2,
+				Name:    "add_publisher",
+				Up:      ms[1].Up,
+				Down: func(ctx context.Context, c schemable.Client) error {
+					return nil
+				},
+			})
+			if err := m2.DownTo(ctx, 0); err != nil {
+				t.Fatal(err)
+			}
+			assertApplied(t, m2, false, false)
+		})
+
+		t.Run("version 0", func(t *testing.T) {
+			var reverted bool
+			m3 := migrate.New(c, &migrate.Migration{
+				Version: 0,
+				Name:    "zero",
+				Up: func(ctx context.Context, c schemable.Client) error {
+					return nil
+				},
+				Down: func(ctx context.Context, c schemable.Client) error {
+					reverted = true
+					return nil
+				},
+			})
+			m3.SetTable("test_migrations")
+			if err := m3.Up(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := m3.DownTo(ctx, 0); err != nil {
+				t.Fatal(err)
+			}
+			assertApplied(t, m3, true)
+			if err := m3.DownTo(ctx, -1); err != nil {
+				t.Fatal(err)
+			}
+			assertApplied(t, m3, false)
+			if !reverted {
+				t.Error("version 0 not reverted")
+			}
+
+			negative := migrate.New(c, &migrate.Migration{Version: -1, Name: "negative", Up: m3.Migrations()[0].Up})
+			negative.SetTable("test_migrations")
+			if err := negative.Up(ctx); err == nil || !strings.Contains(err.Error(), "negative version") {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("lock", func(t *testing.T) {
+			if d := dc.Dialect(); d != schemable.Postgres && d != schemable.MySQL {
+				t.Skip("no migration lock on", d)
+			}
+			var running, overlaps int32
+			slow := &migrate.Migration{
+				Version: 10,
+				Name:    "slow",
+				Up: func(ctx context.Context, c schemable.Client) error {
+					if atomic.AddInt32(&running, 1) > 1 {
+						atomic.AddInt32(&overlaps, 1)
+					}
+					time.Sleep(50 * time.Millisecond)
+					atomic.AddInt32(&running, -1)
+					return nil
+				},
+				Down: func(ctx context.Context, c schemable.Client) error {
+					return nil
+				},
+			}
+			var wg sync.WaitGroup
+			errs := make([]error, 2)
+			for i := range errs {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					lm := migrate.New(c, slow)
+					lm.SetTable("test_migrations")
+					errs[i] = lm.Up(ctx)
+				}(i)
+			}
+			wg.Wait()
+			for _, err := range errs {
+				if err != nil {
+					t.Error(err)
+				}
+			}
+			if overlaps > 0 {
+				t.Error("migrations ran concurrently")
+			}
+
+			lm := migrate.New(c, slow)
+			lm.SetTable("test_migrations")
+			if err := lm.DownTo(ctx, -1); err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+}
+
+func assertApplied(t *testing.T, m *migrate.Migrator, applied ...bool) {
+	t.Helper()
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != len(applied) {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	for i, st := range statuses {
+		if st.Applied != applied[i] {
+			t.Errorf("migration %d %s applied: %t", st.Version, st.Name, st.Applied)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package schemabletest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func PartialTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("partial columns", func(t *testing.T) {
+		ctx := schemable.WithClient(context.Background(), dc)
+		for i, name := range []string{"partial a", "partial b"} {
+			rec := ComicTitles.Record(&ComicTitle{ID2: 470, Name: name, Volume: i + 1})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		defer ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+			return q.Where(titleID2.Eq(470))
+		})
+
+		var recs []*schemable.Recorder[ComicTitle]
+		t.Run("ListColumns()", func(t *testing.T) {
+			var err error
+			recs, err = ComicTitles.ListColumns(ctx, []string{"name"}, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q.Where(titleID2.Eq(470)).OrderBy(titleName.Asc())
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != 2 {
+				t.Fatalf("unexpected records: %+v", recs)
+			}
+			if r := recs[0].Target; r.ID == 0 || r.ID2 != 470 || r.Name != "partial a" || r.Volume != 0 {
+				t.Errorf("unexpected record: %+v", r)
+			}
+			if !recs[0].Loaded("name") || recs[0].Loaded("volume") {
+				t.Error("unexpected loaded columns")
+			}
+			if vals := recs[0].UpdatedValues(); len(vals) != 0 {
+				t.Errorf("unexpected updated values: %+v", vals)
+			}
+
+			_, err = ComicTitles.ListColumns(ctx, []string{"missing"}, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q
+			})
+			if err == nil || !strings.Contains(err.Error(), `no column "missing"`) {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("Update() skips unloaded columns", func(t *testing.T) {
+			rec := recs[0]
+			rec.Target.Name = "partial a2"
+			if vals := rec.UpdatedValues(); len(vals) != 1 || vals["name"] != "partial a2" {
+				t.Errorf("unexpected updated values: %+v", vals)
+			}
+			if err := rec.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Loaded("volume") {
+				t.Error("volume loaded by update")
+			}
+
+			loaded := ComicTitles.Record(&ComicTitle{ID: rec.Target.ID, ID2: 470})
+			if err := loaded.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Target.Name != "partial a2" || loaded.Target.Volume != 1 {
+				t.Errorf("unexpected record: %+v", loaded.Target)
+			}
+
+			rec.Target.Volume = 5
+			if err := rec.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if !rec.Loaded("volume") {
+				t.Error("updated volume not loaded")
+			}
+			if err := loaded.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Target.Volume != 5 {
+				t.Errorf("unexpected record: %+v", loaded.Target)
+			}
+		})
+
+		t.Run("LoadColumns()", func(t *testing.T) {
+			rec := ComicTitles.Record(&ComicTitle{ID: recs[1].Target.ID, ID2: 470})
+			if err := rec.LoadColumns(ctx, "volume"); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.Volume != 2 || rec.Target.Name != "" {
+				t.Errorf("unexpected record: %+v", rec.Target)
+			}
+			if rec.Loaded("name") || !rec.Loaded("volume") {
+				t.Error("unexpected loaded columns")
+			}
+			if vals := rec.UpdatedValues(); len(vals) != 0 {
+				t.Errorf("unexpected updated values: %+v", vals)
+			}
+
+			rec.Target.Volume = 3
+			if err := rec.LoadColumns(ctx, "name"); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.Name != "partial b" || !rec.Loaded("name") {
+				t.Errorf("unexpected record: %+v", rec.Target)
+			}
+			if vals := rec.UpdatedValues(); len(vals) != 1 || vals["volume"] != 3 {
+				t.Errorf("unexpected updated values: %+v", vals)
+			}
+
+			if err := rec.LoadColumns(ctx, "id_three"); err == nil {
+				t.Error("expected error for unknown column")
+			}
+		})
+
+		d := dc.Dialect()
+		if d == schemable.UnknownDialect {
+			return
+		}
+
+		t.Run("blob columns", func(t *testing.T) {
+			stmts, err := ddlRecords.CreateTableSQL(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := dc.Exec(ctx, stmts[0]); err != nil {
+				t.Fatal(err)
+			}
+			defer dc.Exec(ctx, "DROP TABLE ddl_records")
+
+			rec := ddlRecords.Record(&ddlRecord{Code: "blob", Data: []byte("large"), Created: time.Now().UTC()})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if vals := rec.UpdatedValues(); len(vals) != 0 {
+				t.Errorf("unexpected updated values: %+v", vals)
+			}
+
+			recs, err := ddlRecords.ListColumns(ctx, []string{"code"}, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != 1 || recs[0].Target.Data != nil {
+				t.Fatalf("unexpected records: %+v", recs)
+			}
+			recs[0].Target.Code = "blob2"
+			if err := recs[0].Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded := ddlRecords.Record(&ddlRecord{ID: rec.Target.ID})
+			if err := loaded.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if loaded.Target.Code != "blob2" || string(loaded.Target.Data) != "large" {
+				t.Errorf("unexpected record: %+v", loaded.Target)
+			}
+		})
+	})
+}
@@ -0,0 +1,142 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func ReadOnlyTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("ReadOnlyClient", func(t *testing.T) {
+		var writes int
+		c := schemable.FromDB(dc.DB())
+		c.Use(func(next schemable.Handler) schemable.Handler {
+			return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+				if stmt.Kind == schemable.ExecStatement {
+					writes++
+				}
+				return next(ctx, stmt)
+			}
+		})
+
+		ro := c.ReadOnly()
+		if ro.Client() != c {
+			t.Errorf("unexpected client: %+v", ro.Client())
+		}
+		ctx := schemable.WithClient(context.Background(), ro)
+		wctx := schemable.WithClient(context.Background(), c)
+
+		t.Run("reads", func(t *testing.T) {
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.Name != "one" {
+				recorderErr(t, rec)
+			}
+
+			recs, err := ComicTitles.List(ctx, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) == 0 {
+				t.Error("no records")
+			}
+		})
+
+		t.Run("writes", func(t *testing.T) {
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    360,
+				Name:   "read only",
+				Volume: 360,
+			})
+			if err := rec.Insert(ctx); err != schemable.ErrReadOnly {
+				t.Fatalf("unexpected Insert() error: %+v", err)
+			}
+
+			if err := rec.Insert(wctx); err != nil {
+				t.Fatal(err)
+			}
+			writes = 0
+
+			rec.Target.Volume = 361
+			if err := rec.Update(ctx); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected Update() error: %+v", err)
+			}
+			if err := rec.Delete(ctx); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected Delete() error: %+v", err)
+			}
+			if _, err := ComicTitles.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+				return q.Where("id_two = ?", 360)
+			}); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected DeleteWhere() error: %+v", err)
+			}
+			if _, err := ro.Exec(ctx, "DELETE FROM comic_titles"); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected Exec() error: %+v", err)
+			}
+			if _, err := ro.Query(ctx, "DELETE FROM comic_titles RETURNING id"); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected Query() error: %+v", err)
+			}
+			var id int64
+			if err := ro.QueryRow(ctx, "UPDATE comic_titles SET volume = 1 RETURNING id").Scan(&id); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected QueryRow() error: %+v", err)
+			}
+			if writes != 0 {
+				t.Errorf("unexpected writes: %d", writes)
+			}
+
+			assertExists(t, ctx, rec)
+			rec.Target.Volume = 360
+			if err := rec.Delete(wctx); err != nil {
+				t.Fatal(err)
+			}
+			refuteExists(t, ctx, rec)
+		})
+
+		t.Run("transactions", func(t *testing.T) {
+			tctx, tc, err := schemable.WithTransaction(ctx, &sql.TxOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			if err := rec.Load(tctx); err != nil {
+				t.Fatal(err)
+			}
+
+			writes = 0
+			rec.Target.Volume = 361
+			if err := rec.Update(tctx); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected Update() error: %+v", err)
+			}
+			if _, err := tc.Exec(tctx, "DELETE FROM comic_titles"); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected Exec() error: %+v", err)
+			}
+			if writes != 2 {
+				t.Errorf("expected middleware before the read-only guard, got %d writes", writes)
+			}
+
+			if err := tc.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		t.Run("TxOptions.ReadOnly", func(t *testing.T) {
+			tc, err := c.Begin(context.Background(), &sql.TxOptions{ReadOnly: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			tctx := schemable.WithClient(context.Background(), tc)
+			rec := ComicTitles.Record(&ComicTitle{ID2: 362, Name: "read only txn", Volume: 362})
+			if err := rec.Insert(tctx); err != schemable.ErrReadOnly {
+				t.Errorf("unexpected Insert() error: %+v", err)
+			}
+		})
+	})
+}
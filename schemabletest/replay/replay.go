@@ -0,0 +1,317 @@
+// Package replay records the queries of a live schemable client to a JSON
+// fixture, and replays them without a database for fast, hermetic tests.
+//
+//	if *record {
+//		rec := replay.Record(liveClient, "testdata/comics.json")
+//		defer rec.Save()
+//		client = liveClient
+//	} else {
+//		player, err := replay.Load("testdata/comics.json")
+//		defer player.Done()
+//		client = player.DBClient
+//	}
+//
+// Replay is strict: statements must run in the recorded order with the
+// recorded SQL and args, or they fail with a *MismatchError.
+package replay
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/internal/sqlstub"
+)
+
+// Fixture is the JSON file format of recorded statements.
+type Fixture struct {
+	Entries []Entry `json:"entries"`
+}
+
+// Entry is a recorded statement and its result.
+type Entry struct {
+	// Kind is "exec", "query", or "query_row".
+	Kind    string    `json:"kind"`
+	SQL     string    `json:"sql"`
+	Args    []Value   `json:"args,omitempty"`
+	Columns []string  `json:"columns,omitempty"`
+	Rows    [][]Value `json:"rows,omitempty"`
+
+	LastInsertID int64  `json:"last_insert_id,omitempty"`
+	RowsAffected int64  `json:"rows_affected,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Recording records the statements of a live DBClient.
+type Recording struct {
+	path    string
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// Record adds middleware to the given client that records every statement
+// and its result. Transactions begun after this are recorded too. Call Save
+// to write the fixture to the given path.
+func Record(c *schemable.DBClient, path string) *Recording {
+	r := &Recording{path: path}
+	c.Use(r.middleware)
+	return r
+}
+
+// Entries returns the statements recorded so far.
+func (r *Recording) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Save writes the recorded statements to the fixture path, creating its
+// directory if needed.
+func (r *Recording) Save() error {
+	b, err := json.MarshalIndent(Fixture{Entries: r.Entries()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(b, '\n'), 0o644)
+}
+
+func (r *Recording) middleware(next schemable.Handler) schemable.Handler {
+	return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+		e := Entry{Kind: kindName(stmt.Kind), SQL: stmt.SQL}
+		var err error
+		if e.Args, err = values(stmt.Args); err != nil {
+			return schemable.Response{Err: fmt.Errorf("replay: args of %q: %w", stmt.SQL, err)}
+		}
+
+		var res schemable.Response
+		if stmt.Kind == schemable.ExecStatement {
+			res = next(ctx, stmt)
+			if res.Err == nil {
+				e.LastInsertID, _ = res.Result.LastInsertId()
+				e.RowsAffected, _ = res.Result.RowsAffected()
+			}
+		} else {
+			// query rows for both Query and QueryRow, since a *sql.Row can't be
+			// read without scanning it.
+			query := stmt
+			query.Kind = schemable.QueryStatement
+			res = next(ctx, query)
+			if res.Err == nil {
+				res = e.readRows(stmt.Kind, res.Rows)
+			}
+		}
+		if res.Err != nil {
+			e.Error = res.Err.Error()
+		}
+
+		r.mu.Lock()
+		r.entries = append(r.entries, e)
+		r.mu.Unlock()
+		return res
+	}
+}
+
+// readRows records the rows, returning a response that replays them.
+func (e *Entry) readRows(kind schemable.StatementKind, rows *sql.Rows) schemable.Response {
+	defer rows.Close()
+
+	var err error
+	if e.Columns, err = rows.Columns(); err != nil {
+		return schemable.Response{Err: err}
+	}
+	for rows.Next() {
+		dest := make([]any, len(e.Columns))
+		refs := make([]any, len(dest))
+		for i := range dest {
+			refs[i] = &dest[i]
+		}
+		if err := rows.Scan(refs...); err != nil {
+			return schemable.Response{Err: err}
+		}
+		row, err := values(dest)
+		if err != nil {
+			return schemable.Response{Err: err}
+		}
+		e.Rows = append(e.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return schemable.Response{Err: err}
+	}
+	return e.response(kind)
+}
+
+// Player is a DBClient that serves recorded results without a database.
+type Player struct {
+	*schemable.DBClient
+	mu       sync.Mutex
+	entries  []Entry
+	next     int
+	mismatch error
+}
+
+// Load returns a Player for the fixture at the given path.
+func Load(path string) (*Player, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("replay: %s: %w", path, err)
+	}
+	return NewPlayer(f.Entries), nil
+}
+
+// NewPlayer returns a Player for the given entries.
+func NewPlayer(entries []Entry) *Player {
+	p := &Player{DBClient: schemable.FromDB(sqlstub.DB()), entries: entries}
+	p.Use(p.middleware)
+	return p
+}
+
+// Done returns the first mismatch, or an error if any recorded statements
+// were not replayed.
+func (p *Player) Done() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mismatch != nil {
+		return p.mismatch
+	}
+	if n := len(p.entries) - p.next; n > 0 {
+		return fmt.Errorf("replay: %d recorded statements not replayed, starting with %q", n, p.entries[p.next].SQL)
+	}
+	return nil
+}
+
+func (p *Player) middleware(next schemable.Handler) schemable.Handler {
+	return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+		e, err := p.take(stmt)
+		if err != nil {
+			return schemable.Response{Err: err}
+		}
+		if e.Error != "" {
+			return schemable.Response{Err: errors.New(e.Error)}
+		}
+		if stmt.Kind == schemable.ExecStatement {
+			return schemable.Response{Result: result{e.LastInsertID, e.RowsAffected}}
+		}
+		return e.response(stmt.Kind)
+	}
+}
+
+// take returns the next entry if it matches the given statement.
+func (p *Player) take(stmt schemable.Statement) (*Entry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mismatch != nil {
+		return nil, p.mismatch
+	}
+
+	merr := &MismatchError{Index: p.next, Kind: kindName(stmt.Kind), SQL: stmt.SQL, Args: stmt.Args}
+	if p.next >= len(p.entries) {
+		p.mismatch = merr
+		return nil, merr
+	}
+
+	e := &p.entries[p.next]
+	merr.Want = e
+	args, err := values(stmt.Args)
+	if err != nil || e.Kind != merr.Kind || e.SQL != stmt.SQL || !sameValues(e.Args, args) {
+		p.mismatch = merr
+		return nil, merr
+	}
+	p.next++
+	return e, nil
+}
+
+// MismatchError is returned when a replayed statement differs from the
+// recorded one.
+type MismatchError struct {
+	// Index is the position of the statement, starting at 0.
+	Index int
+	Kind  string
+	SQL   string
+	Args  []any
+	// Want is the recorded entry, or nil if every entry was replayed.
+	Want *Entry
+}
+
+func (e *MismatchError) Error() string {
+	if e.Want == nil {
+		return fmt.Sprintf("replay: unexpected statement %d, no more recorded:\n  got:  %s %s %v", e.Index, e.Kind, e.SQL, e.Args)
+	}
+	want, _ := json.Marshal(e.Want.Args)
+	return fmt.Sprintf("replay: statement %d differs from recording:\n  want: %s %s %s\n  got:  %s %s %v",
+		e.Index, e.Want.Kind, e.Want.SQL, want, e.Kind, e.SQL, e.Args)
+}
+
+// response returns the recorded rows as a Query or QueryRow response.
+func (e *Entry) response(kind schemable.StatementKind) schemable.Response {
+	rows := make([][]any, len(e.Rows))
+	for i, r := range e.Rows {
+		rows[i] = make([]any, len(r))
+		for j, v := range r {
+			rows[i][j] = v.V
+		}
+	}
+	if kind == schemable.QueryRowStatement {
+		return schemable.Response{Row: sqlstub.Row(e.Columns, rows)}
+	}
+	res, err := sqlstub.Rows(e.Columns, rows)
+	return schemable.Response{Rows: res, Err: err}
+}
+
+type result struct {
+	lastInsertID, rowsAffected int64
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+func kindName(k schemable.StatementKind) string {
+	switch k {
+	case schemable.ExecStatement:
+		return "exec"
+	case schemable.QueryRowStatement:
+		return "query_row"
+	}
+	return "query"
+}
+
+// values converts args or scanned columns to Values.
+func values(vs []any) ([]Value, error) {
+	if len(vs) == 0 {
+		return nil, nil
+	}
+	converted := make([]Value, len(vs))
+	for i, v := range vs {
+		dv, err := driver.DefaultParameterConverter.ConvertValue(v)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = Value{V: dv}
+	}
+	return converted, nil
+}
+
+func sameValues(a, b []Value) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
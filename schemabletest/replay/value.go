@@ -0,0 +1,84 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Value is a driver value that keeps its type through JSON. Integers, floats,
+// strings, bools, and NULL are plain JSON values. Byte slices, times, and
+// floats that are NaN or infinite are objects with a "bytes", "time", or
+// "float" key.
+type Value struct {
+	V any
+}
+
+type taggedValue struct {
+	Bytes *string `json:"bytes,omitempty"`
+	Time  *string `json:"time,omitempty"`
+	Float *string `json:"float,omitempty"`
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch x := v.V.(type) {
+	case []byte:
+		s := base64.StdEncoding.EncodeToString(x)
+		return json.Marshal(taggedValue{Bytes: &s})
+	case time.Time:
+		s := x.Format(time.RFC3339Nano)
+		return json.Marshal(taggedValue{Time: &s})
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			s := strconv.FormatFloat(x, 'g', -1, 64)
+			return json.Marshal(taggedValue{Float: &s})
+		}
+		// keep a decimal point, so that the value is read back as a float
+		s := strconv.FormatFloat(x, 'g', -1, 64)
+		if !bytes.ContainsAny([]byte(s), ".eE") {
+			s += ".0"
+		}
+		return []byte(s), nil
+	}
+	return json.Marshal(v.V)
+}
+
+func (v *Value) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	switch {
+	case len(b) > 0 && b[0] == '{':
+		var t taggedValue
+		if err := json.Unmarshal(b, &t); err != nil {
+			return err
+		}
+		switch {
+		case t.Bytes != nil:
+			bs, err := base64.StdEncoding.DecodeString(*t.Bytes)
+			v.V = bs
+			return err
+		case t.Time != nil:
+			tm, err := time.Parse(time.RFC3339Nano, *t.Time)
+			v.V = tm
+			return err
+		case t.Float != nil:
+			f, err := strconv.ParseFloat(*t.Float, 64)
+			v.V = f
+			return err
+		}
+		return fmt.Errorf("replay: unknown value %s", b)
+
+	case len(b) > 0 && (b[0] == '-' || b[0] >= '0' && b[0] <= '9'):
+		if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			v.V = i
+			return nil
+		}
+		f, err := strconv.ParseFloat(string(b), 64)
+		v.V = f
+		return err
+	}
+	return json.Unmarshal(b, &v.V)
+}
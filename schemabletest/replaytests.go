@@ -0,0 +1,157 @@
+package schemabletest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/schemabletest/replay"
+)
+
+func ReplayTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("replay", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "testdata", "replay.json")
+
+		// run performs the same operations live and in replay.
+		run := func(t *testing.T, ctx context.Context) (*ComicTitle, []*ComicTitle, error) {
+			t.Helper()
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    390,
+				Name:   "replayed",
+				Volume: 390,
+			})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded := ComicTitles.Record(&ComicTitle{ID: rec.Target.ID, ID2: 390})
+			if err := loaded.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			recs, err := ComicTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q.Where(sq.Eq{"id_two": []int64{1, 390}}).OrderBy("id")
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := rec.Delete(ctx); err != nil {
+				t.Fatal(err)
+			}
+			refuteExists(t, ctx, rec)
+
+			err = rec.LoadWhere(ctx, "missing_column = ?", 1)
+			return loaded.Target, schemable.Targets(recs), err
+		}
+
+		c := schemable.FromDB(dc.DB())
+		recording := replay.Record(c, path)
+		liveRec, liveList, liveErr := run(t, schemable.WithClient(context.Background(), c))
+		if liveErr == nil {
+			t.Fatal("expected live error")
+		}
+		if n := len(recording.Entries()); n != 6 {
+			t.Errorf("unexpected entries: %d", n)
+		}
+		if err := recording.Save(); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run("Load()", func(t *testing.T) {
+			player, err := replay.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := schemable.WithClient(context.Background(), player)
+
+			rec, list, err := run(t, ctx)
+			if err == nil || err.Error() != liveErr.Error() {
+				t.Errorf("unexpected error: %+v", err)
+			}
+			if *rec != *liveRec {
+				t.Errorf("unexpected record: %+v", rec)
+			}
+			if len(list) != len(liveList) || len(list) < 2 {
+				t.Fatalf("unexpected list: %+v", list)
+			}
+			for i := range list {
+				if *list[i] != *liveList[i] {
+					t.Errorf("unexpected record %d: %+v", i, list[i])
+				}
+			}
+			if err := player.Done(); err != nil {
+				t.Error(err)
+			}
+		})
+
+		t.Run("mismatch", func(t *testing.T) {
+			player, err := replay.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := schemable.WithClient(context.Background(), player)
+
+			rec := ComicTitles.Record(&ComicTitle{ID2: 390, Name: "different", Volume: 390})
+			err = rec.Insert(ctx)
+			var merr *replay.MismatchError
+			if !errors.As(err, &merr) {
+				t.Fatalf("unexpected error: %T %+v", err, err)
+			}
+			if merr.Index != 0 || merr.Want == nil || merr.Want.Kind != "exec" {
+				t.Errorf("unexpected mismatch: %+v", merr)
+			}
+
+			if err := player.Done(); err != merr {
+				t.Errorf("unexpected Done() error: %+v", err)
+			}
+		})
+
+		t.Run("unplayed", func(t *testing.T) {
+			player, err := replay.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := player.Done(); err == nil {
+				t.Error("expected error for unplayed statements")
+			}
+		})
+
+		t.Run("Value", func(t *testing.T) {
+			now := time.Now().UTC()
+			vals := []replay.Value{{V: nil}, {V: int64(1)}, {V: 2.0}, {V: "s"}, {V: true}, {V: []byte("b")}, {V: now},
+				{V: math.Inf(1)}, {V: math.Inf(-1)}, {V: math.NaN()}}
+			b, err := json.Marshal(vals)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var decoded []replay.Value
+			if err := json.Unmarshal(b, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded[:5], vals[:5]) {
+				t.Errorf("unexpected values: %s => %+v", b, decoded)
+			}
+			if bs, ok := decoded[5].V.([]byte); !ok || string(bs) != "b" {
+				t.Errorf("unexpected bytes: %+v", decoded[5])
+			}
+			if tm, ok := decoded[6].V.(time.Time); !ok || !tm.Equal(now) {
+				t.Errorf("unexpected time: %+v", decoded[6])
+			}
+			if !reflect.DeepEqual(decoded[7:9], vals[7:9]) {
+				t.Errorf("unexpected infinities: %s => %+v", b, decoded[7:9])
+			}
+			if f, ok := decoded[9].V.(float64); !ok || !math.IsNaN(f) {
+				t.Errorf("unexpected NaN: %+v", decoded[9])
+			}
+		})
+	})
+}
@@ -0,0 +1,227 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/refractionist/schemable"
+)
+
+func RetryTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("RetryPolicy", func(t *testing.T) {
+		if d := dc.Dialect(); d == schemable.UnknownDialect {
+			t.Errorf("unknown dialect for %T", dc.DB().Driver())
+		}
+
+		var failures, calls int
+		var hang bool
+		failErr := driver.ErrBadConn
+		c := schemable.FromDB(dc.DB())
+		events := &eventLog{}
+		c.SetEventLogger(events)
+		c.SetRetryPolicy(schemable.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    2 * time.Millisecond,
+			Jitter:      0.5,
+		})
+		c.Use(func(next schemable.Handler) schemable.Handler {
+			return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+				calls++
+				if hang {
+					<-ctx.Done()
+					return schemable.Response{Err: ctx.Err()}
+				}
+				if failures > 0 {
+					failures--
+					return schemable.Response{Err: failErr}
+				}
+				return next(ctx, stmt)
+			}
+		})
+		ctx := schemable.WithClient(context.Background(), c)
+		rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+
+		reset := func(n int) {
+			failures, calls = n, 0
+			events.reset()
+		}
+
+		t.Run("reads", func(t *testing.T) {
+			reset(2)
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if calls != 3 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+
+			if len(events.events) != 3 {
+				t.Fatalf("unexpected events: %+v", events.events)
+			}
+			for i, e := range events.events {
+				if e.Attempt != i+1 || e.Op != schemable.OpSelect || e.Table != "comic_titles" {
+					t.Errorf("unexpected event %d: %+v", i, e)
+				}
+			}
+			if e := events.events[0]; e.Err != driver.ErrBadConn {
+				t.Errorf("unexpected first event: %+v", e)
+			}
+			if e := events.events[2]; e.Err != nil || e.RowsReturned != 1 {
+				t.Errorf("unexpected last event: %+v", e)
+			}
+
+			reset(1)
+			if _, err := ComicTitles.List(ctx, 1, 0); err != nil {
+				t.Fatal(err)
+			}
+			if calls != 2 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+		})
+
+		t.Run("max attempts", func(t *testing.T) {
+			reset(5)
+			if err := rec.Load(ctx); err != driver.ErrBadConn {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if calls != 3 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+		})
+
+		t.Run("permanent errors", func(t *testing.T) {
+			failErr = errors.New("syntax error")
+			defer func() { failErr = driver.ErrBadConn }()
+
+			reset(1)
+			if err := rec.Load(ctx); err != failErr {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if calls != 1 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+		})
+
+		t.Run("writes", func(t *testing.T) {
+			reset(1)
+			rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			reset(1)
+			rec.Target.Volume = 101
+			if err := rec.Update(ctx); err != driver.ErrBadConn {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if calls != 1 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+
+			reset(1)
+			if err := rec.Update(schemable.WithIdempotent(ctx)); err != nil {
+				t.Fatal(err)
+			}
+			if calls != 2 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+
+			rec.Target.Volume = 100
+			if err := rec.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		t.Run("TxnClient", func(t *testing.T) {
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			reset(1)
+			if err := rec.Load(tctx); err != driver.ErrBadConn {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if calls != 1 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+		})
+
+		t.Run("timeouts", func(t *testing.T) {
+			c.SetTimeouts(schemable.Timeouts{Read: 5 * time.Millisecond})
+			hang = true
+			defer func() {
+				c.SetTimeouts(schemable.Timeouts{})
+				hang = false
+			}()
+
+			reset(0)
+			if err := rec.Load(ctx); !errors.Is(err, schemable.ErrTimeout) {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if calls != 1 {
+				t.Errorf("unexpected calls: %d", calls)
+			}
+		})
+
+		t.Run("canceled context", func(t *testing.T) {
+			c.SetRetryPolicy(schemable.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second})
+			cctx, cancel := context.WithCancel(ctx)
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			reset(2)
+			start := time.Now()
+			if err := rec.Load(cctx); err != driver.ErrBadConn {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+			if calls != 1 || time.Since(start) > 500*time.Millisecond {
+				t.Errorf("unexpected calls: %d in %s", calls, time.Since(start))
+			}
+		})
+
+		t.Run("Dialect.IsTransient()", func(t *testing.T) {
+			tests := []struct {
+				dialect   schemable.Dialect
+				err       error
+				transient bool
+			}{
+				{schemable.UnknownDialect, driver.ErrBadConn, true},
+				{schemable.UnknownDialect, errors.New("database is locked"), false},
+				{schemable.SQLite, errors.New("database is locked"), true},
+				{schemable.SQLite, errors.New("no such table: comics"), false},
+				{schemable.MySQL, errors.New("Error 1213 (40001): Deadlock found when trying to get lock"), true},
+				{schemable.MySQL, errors.New("Error 1062 (23000): Duplicate entry"), false},
+				{schemable.Postgres, errors.New("ERROR: could not serialize access (SQLSTATE 40001)"), true},
+				{schemable.Postgres, sqlStateErr("08006"), true},
+				{schemable.Postgres, sqlStateErr("23505"), false},
+				{schemable.Postgres, context.Canceled, false},
+				{schemable.SQLite, context.DeadlineExceeded, false},
+				{schemable.MySQL, &schemable.TimeoutError{Kind: "read", Err: context.DeadlineExceeded}, false},
+				{schemable.MySQL, &net.OpError{Op: "read", Net: "tcp", Err: &net.DNSError{Err: "i/o timeout", IsTimeout: true}}, false},
+				{schemable.Postgres, &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true},
+			}
+			for _, tt := range tests {
+				if got := tt.dialect.IsTransient(tt.err); got != tt.transient {
+					t.Errorf("%s.IsTransient(%q) = %v", tt.dialect, tt.err, got)
+				}
+			}
+		})
+	})
+}
+
+type sqlStateErr string
+
+func (e sqlStateErr) Error() string {
+	return "pg error " + string(e)
+}
+
+func (e sqlStateErr) SQLState() string {
+	return string(e)
+}
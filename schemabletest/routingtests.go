@@ -0,0 +1,195 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/refractionist/schemable"
+)
+
+func RoutingTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("RoutingClient", func(t *testing.T) {
+		r1 := schemable.FromDB(dc.DB())
+		r2 := schemable.FromDB(dc.DB())
+		rc := schemable.NewRouting(dc, schemable.RoundRobin, r1, r2)
+		ctx := schemable.WithClient(context.Background(), rc)
+
+		t.Run("Reader()", func(t *testing.T) {
+			if r := rc.Reader(ctx); r != r1 {
+				t.Errorf("expected replica 1, got %+v", r)
+			}
+			if r := rc.Reader(ctx); r != r2 {
+				t.Errorf("expected replica 2, got %+v", r)
+			}
+			if r := rc.Reader(ctx); r != r1 {
+				t.Errorf("expected replica 1, got %+v", r)
+			}
+
+			t.Run("without replicas", func(t *testing.T) {
+				rc := schemable.NewRouting(dc, schemable.RoundRobin)
+				if r := rc.Reader(ctx); r != dc {
+					t.Errorf("expected primary, got %+v", r)
+				}
+			})
+		})
+
+		t.Run("WithPrimary()", func(t *testing.T) {
+			pctx := schemable.WithPrimary(ctx)
+			if r := rc.Reader(pctx); r != dc {
+				t.Errorf("expected primary, got %+v", r)
+			}
+		})
+
+		t.Run("WithStickyPrimary()", func(t *testing.T) {
+			sctx := schemable.WithStickyPrimary(ctx)
+			if r := rc.Reader(sctx); r == dc {
+				t.Errorf("expected replica before write, got primary")
+			}
+
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    26,
+				Name:   "routing",
+				Volume: 26,
+			})
+			if err := rec.Insert(sctx); err != nil {
+				t.Fatal(err)
+			}
+
+			if r := rc.Reader(sctx); r != dc {
+				t.Errorf("expected primary after write, got %+v", r)
+			}
+			if r := rc.Reader(ctx); r == dc {
+				t.Errorf("expected replica in other context, got primary")
+			}
+
+			rec2 := ComicTitles.Record(&ComicTitle{ID: rec.Target.ID, ID2: 26})
+			if err := rec2.Load(sctx); err != nil {
+				t.Fatal(err)
+			}
+			if rec2.Target.Name != "routing" {
+				recorderErr(t, rec2)
+			}
+
+			if err := rec.Delete(sctx); err != nil {
+				t.Fatal(err)
+			}
+			refuteExists(t, ctx, rec)
+		})
+
+		t.Run("LeastLatency", func(t *testing.T) {
+			rc := schemable.NewRouting(dc, schemable.LeastLatency, r1, r2)
+			lctx := schemable.WithClient(context.Background(), rc)
+			if r := rc.Reader(lctx); r != r1 {
+				t.Errorf("expected replica 1, got %+v", r)
+			}
+
+			canceled, cancel := context.WithCancel(lctx)
+			cancel()
+			if _, err := rc.Query(canceled, "SELECT 1"); err == nil {
+				t.Fatal("expected error for canceled query")
+			}
+			if r := rc.Reader(lctx); r != r1 {
+				t.Errorf("expected unmeasured replica 1 after failed query, got %+v", r)
+			}
+
+			if _, err := ComicTitles.Exists(lctx, "1 = 1"); err != nil {
+				t.Fatal(err)
+			}
+
+			if r := rc.Reader(lctx); r != r2 {
+				t.Errorf("expected unmeasured replica 2, got %+v", r)
+			}
+		})
+
+		t.Run("failed replica", func(t *testing.T) {
+			down := schemable.FromDB(dc.DB())
+			down.Use(func(next schemable.Handler) schemable.Handler {
+				return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+					return schemable.Response{Err: driver.ErrBadConn}
+				}
+			})
+			up := schemable.FromDB(dc.DB())
+
+			for _, policy := range []schemable.ReplicaPolicy{schemable.LeastLatency, schemable.RoundRobin} {
+				rc := schemable.NewRouting(dc, policy, down, up)
+				fctx := schemable.WithClient(context.Background(), rc)
+				if _, err := rc.Query(fctx, "SELECT 1"); err == nil {
+					t.Fatalf("expected error from down replica with policy %d", policy)
+				}
+				for i := 0; i < 3; i++ {
+					if r := rc.Reader(fctx); r != up {
+						t.Errorf("expected up replica with policy %d, got %+v", policy, r)
+					}
+				}
+			}
+
+			rc := schemable.NewRouting(dc, schemable.LeastLatency, down)
+			fctx := schemable.WithClient(context.Background(), rc)
+			if _, err := ComicTitles.Exists(fctx, "1 = 1"); err == nil {
+				t.Fatal("expected error from down replica")
+			}
+			if r := rc.Reader(fctx); r != dc {
+				t.Errorf("expected primary with every replica down, got %+v", r)
+			}
+		})
+
+		t.Run("Begin()", func(t *testing.T) {
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    27,
+				Name:   "routing txn",
+				Volume: 27,
+			})
+			if err := rec.Insert(tctx); err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, tctx, rec)
+
+			if err := tc.Rollback(); err != nil {
+				t.Fatal(err)
+			}
+			refuteExists(t, ctx, rec)
+
+			t.Run("sticky primary", func(t *testing.T) {
+				sctx := schemable.WithStickyPrimary(ctx)
+				_, tc, err := schemable.WithTransaction(sctx, &sql.TxOptions{ReadOnly: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := tc.Commit(); err != nil {
+					t.Fatal(err)
+				}
+				if r := rc.Reader(sctx); r == dc {
+					t.Errorf("expected replica after read only commit, got primary")
+				}
+
+				_, tc, err = schemable.WithTransaction(sctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r := rc.Reader(sctx); r == dc {
+					t.Errorf("expected replica before commit, got primary")
+				}
+				if err := tc.Commit(); err != nil {
+					t.Fatal(err)
+				}
+				if r := rc.Reader(sctx); r != dc {
+					t.Errorf("expected primary after commit, got %+v", r)
+				}
+			})
+
+			t.Run("nil client", func(t *testing.T) {
+				nctx := schemable.WithClient(context.Background(), (*schemable.RoutingClient)(nil))
+				if _, _, err := schemable.WithTransaction(nctx, nil); err == nil {
+					t.Error("expected error for nil client")
+				}
+			})
+		})
+	})
+}
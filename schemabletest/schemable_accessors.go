@@ -0,0 +1,22 @@
+// Code generated by schemable-accessors. DO NOT EDIT.
+
+package schemabletest
+
+// SchemableColumns returns the columns of accessorTitle's db tagged fields.
+func (t *accessorTitle) SchemableColumns() []string {
+	return []string{"id", "id_two", "name", "volume"}
+}
+
+// SchemableRefs returns pointers to accessorTitle's db tagged fields.
+func (t *accessorTitle) SchemableRefs() []any {
+	return []any{&t.ID, &t.ID2, &t.Name, &t.Volume}
+}
+
+// SchemableValues returns the values of accessorTitle's db tagged fields.
+func (t *accessorTitle) SchemableValues() []any {
+	vals := []any{t.ID, t.ID2, t.Name, t.Volume}
+	if t.Volume != nil {
+		vals[3] = *t.Volume
+	}
+	return vals
+}
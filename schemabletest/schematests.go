@@ -0,0 +1,191 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/refractionist/schemable"
+	"github.com/refractionist/schemable/schemabletest/fake"
+)
+
+type driftRecord struct {
+	ID      int64          `db:"id, PRIMARY KEY"`
+	Name    *string        `db:"name"`
+	Code    string         `db:"code"`
+	Note    sql.NullString `db:"note"`
+	Missing string         `db:"missing"`
+}
+
+var driftRecords = schemable.Bind[driftRecord]("schema_drift")
+
+type autoKeyRecord struct {
+	ID  int64 `db:"id, PRIMARY KEY, AUTO INCREMENT"`
+	ID2 int64 `db:"id_two, PRIMARY KEY"`
+}
+
+var autoKeyRecords = schemable.Bind[autoKeyRecord]("schema_auto_key")
+
+func SchemaTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("CheckSchema()", func(t *testing.T) {
+		t.Run("TableSchema()", func(t *testing.T) {
+			ts := driftRecords.TableSchema()
+			want := []schemable.ColumnSchema{
+				{Name: "id", PrimaryKey: true},
+				{Name: "name", Nullable: true},
+				{Name: "code"},
+				{Name: "note", Nullable: true},
+				{Name: "missing"},
+			}
+			if ts.Table != "schema_drift" || !reflect.DeepEqual(ts.Columns, want) {
+				t.Errorf("unexpected schema: %+v", ts)
+			}
+
+			cols := ComicTitles.TableSchema().Columns
+			if !cols[0].PrimaryKey || !cols[0].AutoIncrement || !cols[1].PrimaryKey || cols[1].AutoIncrement {
+				t.Errorf("unexpected keys: %+v", cols)
+			}
+		})
+
+		t.Run("Postgres Introspect()", func(t *testing.T) {
+			// Postgres drivers return the boolean columns of the Introspect
+			// query as bools, like these fake rows
+			c := fake.New()
+			c.SetDialect(schemable.Postgres)
+			err := c.SeedRows("pg_columns",
+				map[string]any{"name": "id", "type": "bigint", "nullable": false, "pk": true, "auto": true},
+				map[string]any{"name": "name", "type": "text", "nullable": true, "pk": false, "auto": false},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Use(func(next schemable.Handler) schemable.Handler {
+				return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+					if strings.Contains(stmt.SQL, "information_schema.columns") {
+						stmt.SQL, stmt.Args = "SELECT name, type, nullable, pk, auto FROM pg_columns", nil
+					}
+					return next(ctx, stmt)
+				}
+			})
+
+			ts, err := schemable.Introspect(schemable.WithClient(context.Background(), c), "pg_columns")
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []schemable.ColumnSchema{
+				{Name: "id", Type: "bigint", PrimaryKey: true, AutoIncrement: true},
+				{Name: "name", Type: "text", Nullable: true},
+			}
+			if !reflect.DeepEqual(ts.Columns, want) {
+				t.Errorf("unexpected columns: %+v", ts.Columns)
+			}
+		})
+
+		if dc.Dialect() == schemable.UnknownDialect {
+			return
+		}
+
+		ctx := schemable.WithClient(context.Background(), dc)
+
+		t.Run("Introspect()", func(t *testing.T) {
+			ts, err := schemable.Introspect(ctx, "comic_titles")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ts.Columns) != 4 {
+				t.Fatalf("unexpected columns: %+v", ts.Columns)
+			}
+			if id := ts.Column("id"); id == nil || !id.PrimaryKey || id.Nullable || id.Type == "" {
+				t.Errorf("unexpected id column: %+v", id)
+			}
+			if name := ts.Column("name"); name == nil || name.PrimaryKey || name.Nullable {
+				t.Errorf("unexpected name column: %+v", name)
+			}
+
+			_, err = schemable.Introspect(ctx, "schema_missing")
+			if !errors.Is(err, schemable.ErrNoTable) {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("no drift", func(t *testing.T) {
+			if err := schemable.CheckSchema(ctx, ComicTitles); err != nil {
+				t.Error(err)
+			}
+		})
+
+		t.Run("auto increment key", func(t *testing.T) {
+			col := map[schemable.Dialect]string{
+				schemable.SQLite:   "id INTEGER PRIMARY KEY AUTOINCREMENT",
+				schemable.MySQL:    "id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY",
+				schemable.Postgres: "id BIGSERIAL PRIMARY KEY",
+			}[dc.Dialect()]
+			if _, err := dc.Exec(ctx, "CREATE TABLE schema_auto_key ("+col+", id_two BIGINT NOT NULL)"); err != nil {
+				t.Fatal(err)
+			}
+			defer dc.Exec(ctx, "DROP TABLE schema_auto_key")
+
+			err := schemable.CheckSchema(ctx, autoKeyRecords)
+			if dc.Dialect() == schemable.SQLite {
+				if err != nil {
+					t.Errorf("unexpected drift on sqlite: %+v", err)
+				}
+				return
+			}
+			var serr *schemable.SchemaError
+			if !errors.As(err, &serr) || len(serr.Drifts) != 1 || serr.Drifts[0].Kind != schemable.PrimaryKeyMismatch {
+				t.Errorf("unexpected error: %+v", err)
+			}
+		})
+
+		t.Run("drift", func(t *testing.T) {
+			_, err := dc.Exec(ctx, `CREATE TABLE schema_drift (
+  id BIGINT NOT NULL,
+  other_id BIGINT NOT NULL,
+  name VARCHAR(16) NOT NULL,
+  code VARCHAR(16),
+  note VARCHAR(16),
+  extra INTEGER,
+  PRIMARY KEY (id, other_id)
+)`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer dc.Exec(ctx, "DROP TABLE schema_drift")
+
+			missing := schemable.Bind[ComicTitle]("schema_missing")
+			err = schemable.CheckSchema(ctx, ComicTitles, driftRecords, missing)
+			var serr *schemable.SchemaError
+			if !errors.As(err, &serr) {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+
+			want := []struct {
+				kind   schemable.DriftKind
+				column string
+			}{
+				{schemable.NullabilityMismatch, "name"},
+				{schemable.NullabilityMismatch, "code"},
+				{schemable.MissingColumn, "missing"},
+				{schemable.ExtraColumn, "other_id"},
+				{schemable.ExtraColumn, "extra"},
+				{schemable.PrimaryKeyMismatch, ""},
+				{schemable.MissingTable, ""},
+			}
+			if len(serr.Drifts) != len(want) {
+				t.Fatalf("unexpected drifts: %s", serr)
+			}
+			for i, d := range serr.Drifts {
+				if d.Kind != want[i].kind || d.Column != want[i].column {
+					t.Errorf("unexpected drift %d: %s", i, d)
+				}
+			}
+			if d := serr.Drifts[5]; d.String() != "schema_drift: primary key mismatch (want (id), got (id, other_id))" {
+				t.Errorf("unexpected drift: %s", d)
+			}
+		})
+	})
+}
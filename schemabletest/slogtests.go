@@ -0,0 +1,153 @@
+//go:build go1.21
+
+package schemabletest
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/refractionist/schemable"
+)
+
+func SlogTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("SlogLogger", func(t *testing.T) {
+		rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+
+		t.Run("attributes", func(t *testing.T) {
+			h := &slogRecords{}
+			c := schemable.FromDB(dc.DB())
+			c.SetLogger(schemable.NewSlogLogger(slog.New(h), schemable.SlogOptions{}))
+			ctx := schemable.WithClient(context.Background(), c)
+
+			assertExists(t, ctx, rec)
+			if len(h.records) != 1 {
+				t.Fatalf("unexpected records: %+v", h.records)
+			}
+
+			r := h.records[0]
+			if r.Level != slog.LevelInfo || r.Message != "query" {
+				t.Errorf("unexpected record: %+v", r)
+			}
+
+			attrs := slogAttrs(r)
+			if v := attrs["sql"]; v.String() != "SELECT COUNT(*) > 0 FROM comic_titles WHERE comic_titles.id = ? AND comic_titles.id_two = ?" {
+				t.Errorf("unexpected sql: %q", v)
+			}
+			if v := attrs["table"]; v.String() != "comic_titles" {
+				t.Errorf("unexpected table: %q", v)
+			}
+			if v := attrs["op"]; v.String() != "select" {
+				t.Errorf("unexpected op: %q", v)
+			}
+			if v := attrs["duration"]; v.Kind() != slog.KindDuration || v.Duration() <= 0 {
+				t.Errorf("unexpected duration: %+v", v)
+			}
+			if v, ok := attrs["args"].Any().([]any); !ok || len(v) != 2 {
+				t.Errorf("unexpected args: %+v", attrs["args"])
+			}
+		})
+
+		t.Run("LogQuery() without duration", func(t *testing.T) {
+			h := &slogRecords{}
+			l := schemable.NewSlogLogger(slog.New(h), schemable.SlogOptions{})
+			l.LogQuery(context.Background(), "SELECT 1", nil)
+			if len(h.records) != 1 {
+				t.Fatalf("unexpected records: %+v", h.records)
+			}
+			if v := slogAttrs(h.records[0])["duration"]; v.Duration() != 0 {
+				t.Errorf("unexpected duration: %+v", v)
+			}
+		})
+
+		t.Run("SlowThreshold", func(t *testing.T) {
+			h := &slogRecords{}
+			l := schemable.NewSlogLogger(slog.New(h), schemable.SlogOptions{
+				Level:         slog.LevelDebug,
+				SlowThreshold: time.Second,
+			})
+			l.LogQueryEvent(context.Background(), &schemable.QueryEvent{SQL: "fast", Duration: time.Millisecond})
+			l.LogQueryEvent(context.Background(), &schemable.QueryEvent{SQL: "slow", Duration: 2 * time.Second})
+			if len(h.records) != 2 {
+				t.Fatalf("unexpected records: %+v", h.records)
+			}
+			if r := h.records[0]; r.Level != slog.LevelDebug || r.Message != "query" {
+				t.Errorf("unexpected fast record: %+v", r)
+			}
+			if r := h.records[1]; r.Level != slog.LevelWarn || r.Message != "slow query" {
+				t.Errorf("unexpected slow record: %+v", r)
+			}
+		})
+
+		t.Run("SampleRate", func(t *testing.T) {
+			h := &slogRecords{}
+			l := schemable.NewSlogLogger(slog.New(h), schemable.SlogOptions{
+				SlowThreshold: time.Second,
+				SampleRate:    3,
+			})
+			for i := 0; i < 6; i++ {
+				l.LogQueryEvent(context.Background(), &schemable.QueryEvent{SQL: "fast"})
+			}
+			l.LogQueryEvent(context.Background(), &schemable.QueryEvent{SQL: "slow", Duration: time.Second})
+			l.LogQueryEvent(context.Background(), &schemable.QueryEvent{SQL: "failed", Err: context.Canceled})
+			if len(h.records) != 4 {
+				t.Fatalf("unexpected records: %+v", h.records)
+			}
+			if r := h.records[3]; r.Level != slog.LevelError {
+				t.Errorf("unexpected failed record: %+v", r)
+			}
+		})
+
+		t.Run("Redact", func(t *testing.T) {
+			h := &slogRecords{}
+			l := schemable.NewSlogLogger(slog.New(h), schemable.SlogOptions{
+				Redact: func(q string, i int, arg any) any {
+					if i == 1 {
+						return "[REDACTED]"
+					}
+					return arg
+				},
+			})
+			args := []any{"visible", "secret"}
+			l.LogQuery(context.Background(), "SELECT ?, ?", args)
+			logged, _ := slogAttrs(h.records[0])["args"].Any().([]any)
+			if len(logged) != 2 || logged[0] != "visible" || logged[1] != "[REDACTED]" {
+				t.Errorf("unexpected args: %+v", logged)
+			}
+			if args[1] != "secret" {
+				t.Errorf("query args modified: %+v", args)
+			}
+		})
+	})
+}
+
+type slogRecords struct {
+	records []slog.Record
+}
+
+func (h *slogRecords) Enabled(ctx context.Context, l slog.Level) bool {
+	return true
+}
+
+func (h *slogRecords) Handle(ctx context.Context, r slog.Record) error {
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *slogRecords) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h
+}
+
+func (h *slogRecords) WithGroup(name string) slog.Handler {
+	return h
+}
+
+func slogAttrs(r slog.Record) map[string]slog.Value {
+	attrs := make(map[string]slog.Value, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
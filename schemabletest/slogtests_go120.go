@@ -0,0 +1,13 @@
+//go:build !go1.21
+
+package schemabletest
+
+import (
+	"testing"
+
+	"github.com/refractionist/schemable"
+)
+
+// SlogTests is a no-op, since log/slog requires go 1.21.
+func SlogTests(t *testing.T, dc *schemable.DBClient) {
+}
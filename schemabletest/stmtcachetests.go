@@ -0,0 +1,156 @@
+package schemabletest
+
+import (
+	"context"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+func StmtCacheTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Statement cache", func(t *testing.T) {
+		c := schemable.FromDB(dc.DB())
+		c.SetStmtCache(2)
+		ctx := schemable.WithClient(context.Background(), c)
+
+		rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+		for i := 0; i < 3; i++ {
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if rec.Target.Name != "one" {
+			recorderErr(t, rec)
+		}
+
+		if s := c.StmtCacheStats(); s.Hits != 2 || s.Misses != 1 || s.Size != 1 {
+			t.Errorf("unexpected stats: %+v", s)
+		}
+
+		t.Run("eviction", func(t *testing.T) {
+			assertExists(t, ctx, rec)
+			if _, err := ComicTitles.List(ctx, 1, 0); err != nil {
+				t.Fatal(err)
+			}
+
+			s := c.StmtCacheStats()
+			if s.Misses != 3 || s.Evictions != 1 || s.Size != 2 {
+				t.Errorf("unexpected stats: %+v", s)
+			}
+
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if s := c.StmtCacheStats(); s.Misses != 4 {
+				t.Errorf("unexpected stats: %+v", s)
+			}
+		})
+
+		t.Run("Exec", func(t *testing.T) {
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    330,
+				Name:   "cached",
+				Volume: 330,
+			})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Target.ID == 0 {
+				t.Error("no ID set after insert")
+			}
+
+			rec.Target.Volume = 331
+			if err := rec.Update(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if err := rec.Delete(ctx); err != nil {
+				t.Fatal(err)
+			}
+			refuteExists(t, ctx, rec)
+		})
+
+		t.Run("invalid sql", func(t *testing.T) {
+			size := c.StmtCacheStats().Size
+			_, err := ComicTitles.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+				return q.Where("missing_column = 1")
+			})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if s := c.StmtCacheStats(); s.Size != size {
+				t.Errorf("unexpected stats: %+v", s)
+			}
+		})
+
+		t.Run("TxnClient", func(t *testing.T) {
+			c.ClearStmtCache()
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			hits := c.StmtCacheStats().Hits
+			if err := rec.Load(tctx); err != nil {
+				t.Fatal(err)
+			}
+			if s := c.StmtCacheStats(); s.Hits != hits+1 {
+				t.Errorf("unexpected stats: %+v", s)
+			}
+		})
+
+		t.Run("ad-hoc SQL", func(t *testing.T) {
+			before := c.StmtCacheStats()
+			rows, err := c.Query(ctx, "SELECT id FROM comic_titles")
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows.Close()
+			if s := c.StmtCacheStats(); s != before {
+				t.Errorf("ad-hoc SQL was cached: %+v", s)
+			}
+		})
+
+		t.Run("query comments", func(t *testing.T) {
+			c.SetQueryComments(true, nil)
+			defer c.SetQueryComments(false, nil)
+			for _, route := range []string{"/a", "/b"} {
+				if err := rec.Load(schemable.WithQueryTags(ctx, map[string]string{"route": route})); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := c.StmtCacheStats()
+			if err := rec.Load(schemable.WithQueryTags(ctx, map[string]string{"route": "/c"})); err != nil {
+				t.Fatal(err)
+			}
+			if s := c.StmtCacheStats(); s.Hits != before.Hits+1 || s.Size != before.Size {
+				t.Errorf("unexpected stats: %+v", s)
+			}
+		})
+
+		t.Run("ClearStmtCache()", func(t *testing.T) {
+			c.ClearStmtCache()
+			if s := c.StmtCacheStats(); s.Size != 0 {
+				t.Errorf("unexpected stats: %+v", s)
+			}
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		t.Run("disabled", func(t *testing.T) {
+			c.SetStmtCache(0)
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if s := c.StmtCacheStats(); s != (schemable.StmtCacheStats{}) {
+				t.Errorf("unexpected stats: %+v", s)
+			}
+		})
+	})
+}
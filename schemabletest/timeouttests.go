@@ -0,0 +1,183 @@
+package schemabletest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/refractionist/schemable"
+)
+
+func TimeoutTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Timeouts", func(t *testing.T) {
+		c := schemable.FromDB(dc.DB())
+		c.SetTimeouts(schemable.Timeouts{
+			Read:  10 * time.Millisecond,
+			Write: 10 * time.Millisecond,
+			Txn:   50 * time.Millisecond,
+		})
+		ctx := schemable.WithClient(context.Background(), c)
+		rec := ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1})
+
+		t.Run("fast queries", func(t *testing.T) {
+			if err := rec.Load(ctx); err != nil {
+				t.Fatal(err)
+			}
+			recs, err := ComicTitles.List(ctx, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) == 0 {
+				t.Error("no records")
+			}
+		})
+
+		t.Run("released reads", func(t *testing.T) {
+			for _, stmts := range []int{0, 4} {
+				var last context.Context
+				var lag time.Duration
+				rc := schemable.FromDB(dc.DB())
+				rc.SetTimeouts(schemable.Timeouts{Read: 20 * time.Millisecond})
+				rc.SetStmtCache(stmts)
+				rc.Use(func(next schemable.Handler) schemable.Handler {
+					return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+						last = ctx
+						res := next(ctx, stmt)
+						time.Sleep(lag)
+						return res
+					}
+				})
+				rctx := schemable.WithClient(context.Background(), rc)
+
+				if err := rec.Load(rctx); err != nil {
+					t.Fatal(err)
+				}
+				if last.Err() != context.Canceled {
+					t.Errorf("Load() context not released with %d statements: %v", stmts, last.Err())
+				}
+				if recs, err := ComicTitles.List(rctx, 10, 0); err != nil || len(recs) == 0 {
+					t.Fatalf("unexpected List(): %d %+v", len(recs), err)
+				}
+				if last.Err() != context.Canceled {
+					t.Errorf("List() context not released with %d statements: %v", stmts, last.Err())
+				}
+
+				rows, err := rc.Query(rctx, "SELECT name FROM comic_titles")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cols, err := rows.ColumnTypes(); err != nil || len(cols) != 1 {
+					t.Errorf("unexpected column types: %+v %+v", cols, err)
+				}
+				rows.Close()
+
+				// the rows are scanned after the timeout expired
+				lag = 40 * time.Millisecond
+				_, err = ComicTitles.List(rctx, 10, 0)
+				var terr *schemable.TimeoutError
+				if !errors.As(err, &terr) || terr.Kind != "read" || !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("unexpected error with %d statements: %T %+v", stmts, err, err)
+				}
+				rc.ClearStmtCache()
+			}
+		})
+
+		slow := schemable.FromDB(dc.DB())
+		slow.SetTimeouts(schemable.Timeouts{
+			Read:  10 * time.Millisecond,
+			Write: 20 * time.Millisecond,
+		})
+		slow.Use(func(next schemable.Handler) schemable.Handler {
+			return func(ctx context.Context, stmt schemable.Statement) schemable.Response {
+				select {
+				case <-ctx.Done():
+					return schemable.Response{Err: ctx.Err()}
+				case <-time.After(time.Second):
+					return next(ctx, stmt)
+				}
+			}
+		})
+		sctx := schemable.WithClient(context.Background(), slow)
+
+		t.Run("read timeout", func(t *testing.T) {
+			err := rec.Load(sctx)
+			if !errors.Is(err, schemable.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+
+			var terr *schemable.TimeoutError
+			if !errors.As(err, &terr) {
+				t.Fatalf("unexpected error: %T %+v", err, err)
+			}
+			if terr.Kind != "read" || terr.Timeout != 10*time.Millisecond {
+				t.Errorf("unexpected timeout error: %+v", terr)
+			}
+
+			if _, err := ComicTitles.List(sctx, 1, 0); !errors.Is(err, schemable.ErrTimeout) {
+				t.Errorf("unexpected List() error: %+v", err)
+			}
+		})
+
+		t.Run("write timeout", func(t *testing.T) {
+			err := rec.Delete(sctx)
+			var terr *schemable.TimeoutError
+			if !errors.As(err, &terr) {
+				t.Fatalf("unexpected error: %T %+v", err, err)
+			}
+			if terr.Kind != "write" || terr.Timeout != 20*time.Millisecond {
+				t.Errorf("unexpected timeout error: %+v", terr)
+			}
+			assertExists(t, ctx, rec)
+		})
+
+		t.Run("caller deadline", func(t *testing.T) {
+			dctx, cancel := context.WithTimeout(sctx, 5*time.Millisecond)
+			defer cancel()
+
+			err := rec.Load(dctx)
+			if err != context.DeadlineExceeded {
+				t.Errorf("unexpected error: %T %+v", err, err)
+			}
+		})
+
+		t.Run("caller cancellation", func(t *testing.T) {
+			cctx, cancel := context.WithCancel(sctx)
+			time.AfterFunc(time.Millisecond, cancel)
+
+			err := rec.Load(cctx)
+			if err != context.Canceled {
+				t.Errorf("unexpected error: %T %+v", err, err)
+			}
+		})
+
+		t.Run("transaction timeout", func(t *testing.T) {
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, tctx, rec)
+
+			time.Sleep(100 * time.Millisecond)
+			err = tc.Commit()
+			var terr *schemable.TimeoutError
+			if !errors.As(err, &terr) {
+				t.Fatalf("unexpected error: %T %+v", err, err)
+			}
+			if terr.Kind != "transaction" || terr.Timeout != 50*time.Millisecond {
+				t.Errorf("unexpected timeout error: %+v", terr)
+			}
+		})
+
+		t.Run("transaction commit", func(t *testing.T) {
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertExists(t, tctx, rec)
+			if err := tc.Commit(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+}
@@ -0,0 +1,142 @@
+package schemabletest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/refractionist/schemable"
+)
+
+func TracingTests(t *testing.T, dc *schemable.DBClient) {
+	t.Run("Tracer", func(t *testing.T) {
+		tracer := &testTracer{}
+		c := schemable.FromDB(dc.DB())
+		c.SetTracer(tracer)
+		ctx := schemable.WithClient(context.Background(), c)
+
+		t.Run("Insert()", func(t *testing.T) {
+			tracer.spans = nil
+			rec := ComicTitles.Record(&ComicTitle{
+				ID2:    310,
+				Name:   "tracing",
+				Volume: 310,
+			})
+			if err := rec.Insert(ctx); err != nil {
+				t.Fatal(err)
+			}
+			defer rec.Delete(ctx)
+
+			if len(tracer.spans) != 1 {
+				t.Fatalf("unexpected spans: %+v", tracer.spans)
+			}
+
+			s := tracer.spans[0]
+			if s.name != "schemable.Insert comic_titles" {
+				t.Errorf("unexpected name: %q", s.name)
+			}
+			if !s.ended || s.err != nil {
+				t.Errorf("unexpected span: %+v", s)
+			}
+			if v := s.attrs[schemable.AttrTable]; v != "comic_titles" {
+				t.Errorf("unexpected table: %+v", v)
+			}
+			if v := s.attrs[schemable.AttrOperation]; v != "insert" {
+				t.Errorf("unexpected operation: %+v", v)
+			}
+			if v := s.attrs[schemable.AttrStatement]; v != "INSERT INTO comic_titles (id_two,name,volume) VALUES (?,?,?)" {
+				t.Errorf("unexpected statement: %+v", v)
+			}
+			if v := s.attrs[schemable.AttrRowsAffected]; v != int64(1) {
+				t.Errorf("unexpected rows affected: %+v", v)
+			}
+		})
+
+		t.Run("nested spans", func(t *testing.T) {
+			tracer.spans = nil
+			pctx, parent := tracer.Start(ctx, "parent")
+			recs, err := ComicTitles.List(pctx, 2, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parent.End(nil)
+
+			if len(tracer.spans) != 2 {
+				t.Fatalf("unexpected spans: %+v", tracer.spans)
+			}
+
+			s := tracer.spans[1]
+			if s.name != "schemable.ListWhere comic_titles" {
+				t.Errorf("unexpected name: %q", s.name)
+			}
+			if s.parent != tracer.spans[0] {
+				t.Errorf("unexpected parent: %+v", s.parent)
+			}
+			if v := s.attrs[schemable.AttrRowsReturned]; v != int64(len(recs)) {
+				t.Errorf("unexpected rows returned: %+v", v)
+			}
+		})
+
+		t.Run("error status", func(t *testing.T) {
+			tracer.spans = nil
+			rec := ComicTitles.Record(&ComicTitle{ID: 1000, ID2: 1000})
+			if err := rec.Load(ctx); err != sql.ErrNoRows {
+				t.Fatalf("unexpected error: %+v", err)
+			}
+
+			if len(tracer.spans) != 1 {
+				t.Fatalf("unexpected spans: %+v", tracer.spans)
+			}
+			if s := tracer.spans[0]; s.name != "schemable.Load comic_titles" || s.err != sql.ErrNoRows {
+				t.Errorf("unexpected span: %+v", s)
+			}
+		})
+
+		t.Run("TxnClient", func(t *testing.T) {
+			tracer.spans = nil
+			tctx, tc, err := schemable.WithTransaction(ctx, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tc.Rollback()
+
+			assertExists(t, tctx, ComicTitles.Record(&ComicTitle{ID: 1, ID2: 1}))
+			if len(tracer.spans) != 1 || tracer.spans[0].name != "schemable.Exists comic_titles" {
+				t.Errorf("unexpected spans: %+v", tracer.spans)
+			}
+		})
+	})
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+type testSpan struct {
+	name   string
+	parent *testSpan
+	attrs  map[string]any
+	ended  bool
+	err    error
+}
+
+type testSpanKey struct{}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...schemable.Attribute) (context.Context, schemable.Span) {
+	s := &testSpan{name: name, attrs: make(map[string]any)}
+	s.parent, _ = ctx.Value(testSpanKey{}).(*testSpan)
+	s.SetAttributes(attrs...)
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, testSpanKey{}, s), s
+}
+
+func (s *testSpan) SetAttributes(attrs ...schemable.Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+
+func (s *testSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
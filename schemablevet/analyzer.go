@@ -0,0 +1,391 @@
+// Package schemablevet provides a go/analysis Analyzer that checks db struct
+// tags and Schemer usage. It is a separate module to keep schemable's go.mod
+// tidy.
+//
+//	go install github.com/refractionist/schemable/schemablevet/cmd/schemablevet@latest
+//	go vet -vettool=$(which schemablevet) ./...
+package schemablevet
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const pkgPath = "github.com/refractionist/schemable"
+
+// Analyzer checks db tags and Schemer usage:
+//
+//   - invalid db tag options
+//   - duplicate columns in a struct
+//   - unexported fields with db tags
+//   - Bind on a type without primary keys, in a package that uses its Recorder
+//   - string column names in a WhereFunc, DeleteFunc, or predicate that are not
+//     columns of the bound type
+var Analyzer = &analysis.Analyzer{
+	Name:     "schemable",
+	Doc:      "check db struct tags and schemable Schemer usage",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	ins.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		checkStruct(pass, n.(*ast.StructType))
+	})
+
+	// Bind calls on types without primary keys, and the types whose Recorders
+	// are used
+	type bindCall struct {
+		t    types.Type
+		call *ast.CallExpr
+	}
+	var keyless []bindCall
+	recorded := make(map[types.Type]bool)
+
+	ins.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if t := bindType(pass, call); t != nil {
+			if cols, ok := columns(t); ok && !cols.hasKey {
+				keyless = append(keyless, bindCall{t, call})
+			}
+			return
+		}
+
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return
+		}
+		recv, t := receiverType(pass, sel)
+		switch {
+		case recv == "Schemer" && sel.Sel.Name == "Record":
+			recorded[t] = true
+		case recv == "Recorder" && keyMethods[sel.Sel.Name]:
+			recorded[t] = true
+		}
+		checkColumns(pass, call, sel, recv, t)
+	})
+
+	for _, b := range keyless {
+		if recorded[b.t] {
+			pass.Reportf(b.call.Pos(), "Bind on %s without a PRIMARY KEY field, but its Recorder is used", b.t)
+		}
+	}
+	return nil, nil
+}
+
+// keyMethods are the Recorder methods that query by primary keys.
+var keyMethods = map[string]bool{
+	"Load": true, "Exists": true, "Update": true, "Delete": true, "WhereIDs": true,
+}
+
+// checkStruct reports invalid db tags, duplicate columns, and unexported
+// tagged fields.
+func checkStruct(pass *analysis.Pass, st *ast.StructType) {
+	seen := make(map[string]string)
+	for _, f := range st.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		stag := reflect.StructTag(tag).Get("db")
+		if stag == "" {
+			continue
+		}
+
+		parts := parseTag(stag)
+		column := parts[0]
+		if strings.TrimSpace(column) == "" {
+			pass.Reportf(f.Tag.Pos(), "db tag has no column name")
+		}
+		for _, opt := range parts[1:] {
+			if msg := checkOption(strings.TrimSpace(opt)); msg != "" {
+				pass.Reportf(f.Tag.Pos(), "db tag option %q: %s", strings.TrimSpace(opt), msg)
+			}
+		}
+
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				pass.Reportf(name.Pos(), "db tagged field %s is unexported", name.Name)
+			}
+			if other, ok := seen[column]; ok && column != "" {
+				pass.Reportf(name.Pos(), "duplicate column %q in fields %s and %s", column, other, name.Name)
+			}
+			seen[column] = name.Name
+		}
+	}
+}
+
+// checkOption returns a message if the db tag option is invalid.
+func checkOption(opt string) string {
+	switch {
+	case opt == "PRIMARY KEY", opt == "AUTO INCREMENT", opt == "UNIQUE", opt == "INDEX":
+		return ""
+	case strings.HasPrefix(opt, "INDEX "):
+		if !identRe.MatchString(strings.TrimSpace(opt[len("INDEX "):])) {
+			return "invalid index name"
+		}
+		return ""
+	case strings.HasPrefix(opt, "TYPE "):
+		if strings.TrimSpace(opt[len("TYPE "):]) == "" {
+			return "missing type"
+		}
+		return ""
+	case strings.HasPrefix(opt, "SIZE "):
+		if n, err := strconv.Atoi(strings.TrimSpace(opt[len("SIZE "):])); err != nil || n <= 0 {
+			return "size must be a positive integer"
+		}
+		return ""
+	case strings.HasPrefix(opt, "DEFAULT "):
+		if strings.TrimSpace(opt[len("DEFAULT "):]) == "" {
+			return "missing default value"
+		}
+		return ""
+	}
+	return "unknown option"
+}
+
+// parseTag splits a db tag on commas that are not in parentheses or quotes,
+// like schemable's Bind.
+func parseTag(tag string) []string {
+	var parts []string
+	depth, quoted, start := 0, false, 0
+	for i, c := range tag {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, tag[start:])
+}
+
+// structColumns are the columns of a db tagged struct.
+type structColumns struct {
+	names  map[string]bool
+	hasKey bool
+}
+
+// columns returns the columns of the struct type t.
+func columns(t types.Type) (structColumns, bool) {
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return structColumns{}, false
+	}
+	cols := structColumns{names: make(map[string]bool)}
+	for i := 0; i < st.NumFields(); i++ {
+		stag := reflect.StructTag(st.Tag(i)).Get("db")
+		if stag == "" {
+			continue
+		}
+		parts := parseTag(stag)
+		cols.names[parts[0]] = true
+		for _, opt := range parts[1:] {
+			if strings.TrimSpace(opt) == "PRIMARY KEY" {
+				cols.hasKey = true
+			}
+		}
+	}
+	return cols, true
+}
+
+// bindType returns the type argument of a schemable.Bind call, or nil.
+func bindType(pass *analysis.Pass, call *ast.CallExpr) types.Type {
+	var id *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.IndexExpr:
+		id = ident(fun.X)
+	case *ast.IndexListExpr:
+		id = ident(fun.X)
+	default:
+		id = ident(fun)
+	}
+	if id == nil {
+		return nil
+	}
+	fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	if !ok || fn.Name() != "Bind" || fn.Pkg() == nil || fn.Pkg().Path() != pkgPath {
+		return nil
+	}
+	inst, ok := pass.TypesInfo.Instances[id]
+	if !ok || inst.TypeArgs.Len() != 1 {
+		return nil
+	}
+	return inst.TypeArgs.At(0)
+}
+
+func ident(e ast.Expr) *ast.Ident {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
+
+// receiverType returns "Schemer" or "Recorder" and the type argument if the
+// selector is a method of a schemable Schemer or Recorder.
+func receiverType(pass *analysis.Pass, sel *ast.SelectorExpr) (string, types.Type) {
+	tv, ok := pass.TypesInfo.Types[sel.X]
+	if !ok {
+		return "", nil
+	}
+	t := tv.Type
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != pkgPath || named.TypeArgs().Len() != 1 {
+		return "", nil
+	}
+	switch name := named.Obj().Name(); name {
+	case "Schemer", "Recorder":
+		return name, named.TypeArgs().At(0)
+	}
+	return "", nil
+}
+
+// checkColumns reports unknown columns in the WhereFunc, DeleteFunc, or
+// predicate of a Schemer or Recorder method call.
+func checkColumns(pass *analysis.Pass, call *ast.CallExpr, sel *ast.SelectorExpr, recv string, t types.Type) {
+	if recv == "" || len(call.Args) < 2 {
+		return
+	}
+	cols, ok := columns(t)
+	if !ok {
+		return
+	}
+
+	switch {
+	case recv == "Schemer" && (sel.Sel.Name == "First" || sel.Sel.Name == "ListWhere" || sel.Sel.Name == "DeleteWhere"):
+		lit, ok := call.Args[1].(*ast.FuncLit)
+		if !ok {
+			return
+		}
+		ast.Inspect(lit.Body, func(n ast.Node) bool {
+			if c, ok := n.(*ast.CallExpr); ok {
+				if s, ok := c.Fun.(*ast.SelectorExpr); ok && builderMethods[s.Sel.Name] && isBuilder(pass, s.X) {
+					for _, arg := range c.Args {
+						checkPredicate(pass, arg, cols, s.Sel.Name)
+					}
+				}
+			}
+			return true
+		})
+	case recv == "Schemer" && sel.Sel.Name == "Exists", recv == "Recorder" && sel.Sel.Name == "LoadWhere":
+		checkPredicate(pass, call.Args[1], cols, "Where")
+	}
+}
+
+// builderMethods are the squirrel builder methods with column arguments.
+var builderMethods = map[string]bool{
+	"Where": true, "OrderBy": true, "GroupBy": true,
+}
+
+// isBuilder returns true if the expression is a squirrel SelectBuilder or
+// DeleteBuilder.
+func isBuilder(pass *analysis.Pass, e ast.Expr) bool {
+	tv, ok := pass.TypesInfo.Types[e]
+	if !ok {
+		return false
+	}
+	named, ok := tv.Type.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "github.com/Masterminds/squirrel" {
+		return false
+	}
+	return named.Obj().Name() == "SelectBuilder" || named.Obj().Name() == "DeleteBuilder"
+}
+
+var (
+	identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// leading column of a predicate like "name = ?" or an order like "name DESC"
+	leadingColumnRe = regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)?)\s*(?:$|=|<|>|!|(?i:is|in|like|not|between|asc|desc)\b)`)
+)
+
+// checkPredicate reports unknown columns in a string predicate, or in the
+// keys of a squirrel map predicate like sq.Eq.
+func checkPredicate(pass *analysis.Pass, arg ast.Expr, cols structColumns, method string) {
+	if cl, ok := arg.(*ast.CompositeLit); ok {
+		if !isSquirrelMap(pass, cl) {
+			return
+		}
+		for _, elt := range cl.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			if col, ok := stringConst(pass, kv.Key); ok {
+				checkColumn(pass, kv.Key.Pos(), col, cols)
+			}
+		}
+		return
+	}
+
+	s, ok := stringConst(pass, arg)
+	if !ok {
+		return
+	}
+	m := leadingColumnRe.FindStringSubmatch(s)
+	if m == nil {
+		return
+	}
+	if method == "Where" && !strings.ContainsAny(s, "=<>!?") && !strings.Contains(strings.ToUpper(s), " IS ") {
+		// not a comparison, like a bare boolean column or an expression
+		return
+	}
+	checkColumn(pass, arg.Pos(), m[1], cols)
+}
+
+func checkColumn(pass *analysis.Pass, pos token.Pos, col string, cols structColumns) {
+	if i := strings.LastIndexByte(col, '.'); i >= 0 {
+		col = col[i+1:]
+	}
+	if !cols.names[col] {
+		pass.Reportf(pos, "unknown column %q", col)
+	}
+}
+
+// isSquirrelMap returns true if the literal is a squirrel map predicate like
+// sq.Eq or sq.Lt.
+func isSquirrelMap(pass *analysis.Pass, cl *ast.CompositeLit) bool {
+	tv, ok := pass.TypesInfo.Types[cl]
+	if !ok {
+		return false
+	}
+	named, ok := tv.Type.(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "github.com/Masterminds/squirrel" {
+		return false
+	}
+	_, ok = named.Underlying().(*types.Map)
+	return ok
+}
+
+func stringConst(pass *analysis.Pass, e ast.Expr) (string, bool) {
+	tv, ok := pass.TypesInfo.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
@@ -0,0 +1,13 @@
+package schemablevet_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/refractionist/schemable/schemablevet"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), schemablevet.Analyzer, "a", "clean")
+}
@@ -0,0 +1,12 @@
+// Command schemablevet runs the schemable Analyzer, on its own or with
+// go vet -vettool.
+package main
+
+import (
+	"github.com/refractionist/schemable/schemablevet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(schemablevet.Analyzer)
+}
@@ -0,0 +1,12 @@
+module github.com/refractionist/schemable/schemablevet
+
+// x/tools releases that build and run analysistest with current Go
+// toolchains require go 1.22
+go 1.22.0
+
+require golang.org/x/tools v0.26.0
+
+require (
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
+)
@@ -0,0 +1,43 @@
+package a
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+type Comic struct {
+	ID     int64  `db:"id, PRIMARY KEY, AUTO INCREMENT"`
+	Title  string `db:"title, SIZE 0"`     // want `db tag option "SIZE 0": size must be a positive integer`
+	Volume int    `db:"volume, INDEX a-b"` // want `db tag option "INDEX a-b": invalid index name`
+	Price  int    `db:"price, NULLABLE"`   // want `db tag option "NULLABLE": unknown option`
+	Issue  int    `db:", UNIQUE"`          // want `db tag has no column name`
+	Name   string `db:"title"`             // want `duplicate column "title" in fields Title and Name`
+	note   string `db:"note"`              // want `db tagged field note is unexported`
+}
+
+type Log struct {
+	Message string `db:"message"`
+}
+
+var (
+	Comics = schemable.Bind[Comic]("comics")
+	Logs   = schemable.Bind[Log]("logs") // want `Bind on a.Log without a PRIMARY KEY field, but its Recorder is used`
+)
+
+func use(ctx context.Context) {
+	Logs.Record(nil).Insert(ctx)
+
+	Comics.First(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+		return q.Where("titel = ?", "x").OrderBy("volume DESC", "isue ASC") // want `unknown column "titel"` `unknown column "isue"`
+	})
+	Comics.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+		return q.Where(sq.Eq{"comics.title": "x", "colour": "red"}) // want `unknown column "colour"`
+	})
+	Comics.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+		return q.Where("prise > ?", 1) // want `unknown column "prise"`
+	})
+	Comics.Exists(ctx, "vol IS NULL")                           // want `unknown column "vol"`
+	Comics.Record(nil).LoadWhere(ctx, sq.Eq{"id": 1, "ids": 2}) // want `unknown column "ids"`
+}
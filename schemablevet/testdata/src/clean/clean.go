@@ -0,0 +1,40 @@
+package clean
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/refractionist/schemable"
+)
+
+type Comic struct {
+	ID      int64   `db:"id, PRIMARY KEY, AUTO INCREMENT"`
+	Title   string  `db:"title, SIZE 200, INDEX title_idx"`
+	Price   float64 `db:"price, TYPE DECIMAL(10,2), DEFAULT 0"`
+	Code    string  `db:"code, UNIQUE"`
+	Ignored string
+	note    string
+}
+
+type Log struct {
+	Message string `db:"message"`
+}
+
+var (
+	Comics = schemable.Bind[Comic]("comics")
+	// keyless, but its Recorder is never used
+	Logs = schemable.Bind[Log]("logs")
+)
+
+func use(ctx context.Context, title string) {
+	Logs.ListWhere(ctx, func(q sq.SelectBuilder) sq.SelectBuilder { return q })
+
+	Comics.First(ctx, func(q sq.SelectBuilder) sq.SelectBuilder {
+		return q.Where("title = ?", title).Where("price").OrderBy("comics.price DESC")
+	})
+	Comics.DeleteWhere(ctx, func(q sq.DeleteBuilder) sq.DeleteBuilder {
+		return q.Where(sq.Eq{"code": "x"})
+	})
+	Comics.Exists(ctx, "title IS NOT NULL")
+	Comics.Record(nil).Load(ctx)
+}
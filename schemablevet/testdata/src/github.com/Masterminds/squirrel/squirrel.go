@@ -0,0 +1,16 @@
+// Package squirrel is a stub of the squirrel API for analyzer tests.
+package squirrel
+
+type Eq map[string]any
+
+type SelectBuilder struct{}
+
+func (b SelectBuilder) Where(pred any, args ...any) SelectBuilder { return b }
+
+func (b SelectBuilder) OrderBy(orderBys ...string) SelectBuilder { return b }
+
+func (b SelectBuilder) GroupBy(groupBys ...string) SelectBuilder { return b }
+
+type DeleteBuilder struct{}
+
+func (b DeleteBuilder) Where(pred any, args ...any) DeleteBuilder { return b }
@@ -0,0 +1,52 @@
+// Package schemable is a stub of the schemable API for analyzer tests.
+package schemable
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+type WhereFunc func(query sq.SelectBuilder) sq.SelectBuilder
+
+type DeleteFunc func(query sq.DeleteBuilder) sq.DeleteBuilder
+
+type Schemer[T any] struct{}
+
+func Bind[T any](table string) *Schemer[T] {
+	return &Schemer[T]{}
+}
+
+func (s *Schemer[T]) Record(tgt *T) *Recorder[T] {
+	return &Recorder[T]{}
+}
+
+func (s *Schemer[T]) First(ctx context.Context, fn WhereFunc) (*Recorder[T], error) {
+	return nil, nil
+}
+
+func (s *Schemer[T]) ListWhere(ctx context.Context, fn WhereFunc) ([]*Recorder[T], error) {
+	return nil, nil
+}
+
+func (s *Schemer[T]) DeleteWhere(ctx context.Context, fn DeleteFunc) error {
+	return nil
+}
+
+func (s *Schemer[T]) Exists(ctx context.Context, pred any, args ...any) (bool, error) {
+	return false, nil
+}
+
+type Recorder[T any] struct{}
+
+func (r *Recorder[T]) Load(ctx context.Context) error {
+	return nil
+}
+
+func (r *Recorder[T]) LoadWhere(ctx context.Context, pred any, args ...any) error {
+	return nil
+}
+
+func (r *Recorder[T]) Insert(ctx context.Context) error {
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,6 +18,11 @@ type Schemer[T any] struct {
 	fields []*field
 	keys []*field
 	kind reflect.Type
+	// accessors is true if *T implements Accessors for its fields
+	accessors bool
+	cache Cache
+	cacheTTL time.Duration
+	changes changeFeed[T]
 }
 
 // Bind creates a Schemer table/column mapping for the given generic type T.
@@ -28,28 +34,27 @@ func Bind[T any](table string) *Schemer[T] {
 		fields: fields,
 		keys: keys,
 		kind: reflect.TypeOf(tgt).Elem(),
+		accessors: hasAccessors(tgt, fields),
 	}
 }
 
 // First returns a *Recorder[T] of the first row, filtered by the given
-// WhereFunc. The context must have a client embedded with WithClient().
+// WhereFunc. With an IdentityMap in the context, the Recorder's Target is the
+// one already cached for the row, if any. The context must have a client
+// embedded with WithClient().
 func (s *Schemer[T]) First(ctx context.Context, fn WhereFunc) (*Recorder[T], error) {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return nil, ErrNoClient
-	}
-
-	q := fn(c.Builder().Select(s.Columns(true)...).From(s.table)).Limit(1)
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "First", OpSelect, s.table)
 	if err != nil {
 		return nil, err
 	}
 
 	rec := s.Record(nil)
-	start := time.Now()
-	err = c.QueryRow(ctx, qu, args...).Scan(rec.fieldRefs(true)...)
+	b := fn(q.Builder().Select(s.Columns(true)...).From(s.table)).Limit(1)
+	err = q.scanRow(b, rec.fieldRefs(true)...)
 	rec.setValues()
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
+	if err == nil {
+		rec.remember(ctx)
+	}
 	return rec, err
 }
 
@@ -64,19 +69,12 @@ func (s *Schemer[T]) List(ctx context.Context, limit, offset uint64) ([]*Recorde
 // ListWhere returns rows of type T embedded in Recorders, filtered by the
 // given WhereFunc. The context must have a client embedded with WithClient().
 func (s *Schemer[T]) ListWhere(ctx context.Context, fn WhereFunc) ([]*Recorder[T], error) {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return nil, ErrNoClient
-	}
-
-	q := fn(c.Builder().Select(s.Columns(true)...).From(s.table))
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "ListWhere", OpSelect, s.table)
 	if err != nil {
 		return nil, err
 	}
 
-	start := time.Now()
-	rows, err := c.Query(ctx, qu, args...)
+	rows, err := q.rows(fn(q.Builder().Select(s.Columns(true)...).From(s.table)))
 	if err != nil || rows == nil {
 		return nil, err
 	}
@@ -88,53 +86,46 @@ func (s *Schemer[T]) ListWhere(ctx context.Context, fn WhereFunc) ([]*Recorder[T
 		rec = s.Record(nil)
 		err = rows.Scan(rec.fieldRefs(true)...)
 		if err != nil {
-			return recs, err
+			q.event.RowsReturned = int64(len(recs))
+			return recs, q.done(err)
 		}
 		rec.setValues()
 		recs = append(recs, rec)
 	}
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
-	return recs, rows.Err()
+	err = rows.Err()
+	q.event.RowsReturned = int64(len(recs))
+	return recs, q.done(err)
 }
 
-// DeleteWhere deletes rows filtered by the given DeleteFunc. The context must
-// have a client embedded with WithClient().
+// DeleteWhere deletes rows filtered by the given DeleteFunc, dropping the
+// table's Targets from the context's IdentityMap and the Schemer's Cache. The
+// context must have a client embedded with WithClient().
 func (s *Schemer[T]) DeleteWhere(ctx context.Context, fn DeleteFunc) (sql.Result, error) {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return nil, ErrNoClient
-	}
-
-	q := fn(c.Builder().Delete(s.table))
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "DeleteWhere", OpDelete, s.table)
 	if err != nil {
 		return nil, err
 	}
 
-	start := time.Now()
-	res, err := c.Exec(ctx, qu, args...)
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
+	q.invalidate = s.invalidateTable
+	res, err := q.exec(fn(q.Builder().Delete(s.table)))
+	if err == nil {
+		s.forgetTable(ctx)
+		s.publish(ctx, Deleted, nil, nil)
+	}
 	return res, err
 }
 
 // Exists checks if any Recorder Target exists using the given predicate
 // args for a Where clause on the squirrel query builder.
 func (s *Schemer[T]) Exists(ctx context.Context, pred any, args ...any) (bool, error) {
-	c := ClientFrom(ctx)
-	if c == nil {
-		return false, ErrNoClient
-	}
-
-	q := c.Builder().Select("COUNT(*) > 0").From(s.table).Where(pred, args...)
-	qu, args, err := q.ToSql()
+	q, err := startQuery(ctx, "Exists", OpSelect, s.table)
 	if err != nil {
 		return false, err
 	}
 
 	has := false
-	start := time.Now()
-	err = c.QueryRow(ctx, qu, args...).Scan(&has)
-	c.LogQuery(WithDBDuration(ctx, start), qu, args)
+	b := q.Builder().Select("COUNT(*) > 0").From(s.table).Where(pred, args...)
+	err = q.scanRow(b, &has)
 	return has, err
 }
 
@@ -194,8 +185,14 @@ type field struct {
 	isKey bool
 	// Is an auto increment
 	isAuto bool
-	// Is optional
+	// Is optional: a pointer or sql.Null* field
 	isOptional bool
+	// Go type of the struct field
+	kind reflect.Type
+	// DDL options: explicit column type, size, default value, and index name
+	sqlType, defaultValue, index string
+	size int
+	isUnique bool
 }
 
 func scanFields(table string, obj any) (fields []*field, keys []*field) {
@@ -221,16 +218,30 @@ func scanFields(table string, obj any) (fields []*field, keys []*field) {
 			name:         f.Name,
 			column:       parts[0],
 			selectcolumn: table + "." + parts[0],
-			isOptional:   f.Type.Kind() == reflect.Ptr,
+			isOptional:   f.Type.Kind() == reflect.Ptr || nullTypes[f.Type] != nil,
+			kind:         f.Type,
 		}
 
 		for _, part := range parts[1:] {
-			switch strings.TrimSpace(part) {
-				case pkey:
+			part = strings.TrimSpace(part)
+			switch {
+				case part == pkey:
 					field.isKey = true
 					keys = append(keys, field)
-				case autoinc:
+				case part == autoinc:
 					field.isAuto = true
+				case part == unique:
+					field.isUnique = true
+				case part == index:
+					field.index = table + "_" + field.column + "_idx"
+				case strings.HasPrefix(part, index + " "):
+					field.index = strings.TrimSpace(part[len(index):])
+				case strings.HasPrefix(part, typeOpt):
+					field.sqlType = strings.TrimSpace(part[len(typeOpt):])
+				case strings.HasPrefix(part, sizeOpt):
+					field.size, _ = strconv.Atoi(strings.TrimSpace(part[len(sizeOpt):]))
+				case strings.HasPrefix(part, defaultOpt):
+					field.defaultValue = strings.TrimSpace(part[len(defaultOpt):])
 			}
 		}
 
@@ -240,9 +251,26 @@ func scanFields(table string, obj any) (fields []*field, keys []*field) {
 	return
 }
 
-// parseTag parses the contents of a stbl tag.
+// parseTag parses the contents of a stbl tag. Commas in parentheses or
+// quotes, like in "TYPE DECIMAL(10,2)", do not separate options.
 func parseTag(fieldName, tag string) []string {
-	parts := strings.Split(tag, ",")
+	var parts []string
+	depth, quoted, start := 0, false, 0
+	for i, c := range tag {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, tag[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, tag[start:])
 	if len(parts) == 0 {
 		return []string{fieldName}
 	}
@@ -252,4 +280,9 @@ func parseTag(fieldName, tag string) []string {
 const (
 	pkey = "PRIMARY KEY"
 	autoinc = "AUTO INCREMENT"
+	unique = "UNIQUE"
+	index = "INDEX"
+	typeOpt = "TYPE "
+	sizeOpt = "SIZE "
+	defaultOpt = "DEFAULT "
 )
\ No newline at end of file
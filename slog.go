@@ -0,0 +1,114 @@
+//go:build go1.21
+
+package schemable
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// SlogOptions configures a SlogLogger.
+type SlogOptions struct {
+	// Level is the level of logged queries. Defaults to slog.LevelInfo.
+	Level slog.Level
+
+	// SlowThreshold raises queries that take at least this long to
+	// slog.LevelWarn. Disabled if 0.
+	SlowThreshold time.Duration
+
+	// SampleRate logs 1 out of every SampleRate fast queries. Slow queries and
+	// failed queries are always logged. All queries are logged if SampleRate
+	// is 0 or 1.
+	SampleRate uint64
+
+	// Redact returns the value to log for the query arg at index i, so that
+	// sensitive values can be hidden. Args are logged as is if nil.
+	Redact func(q string, i int, arg any) any
+}
+
+// SlogLogger is a QueryLogger and QueryEventLogger that writes queries to a
+// *slog.Logger.
+type SlogLogger struct {
+	logger *slog.Logger
+	opts   SlogOptions
+	count  uint64
+}
+
+// NewSlogLogger returns a SlogLogger that writes to the given *slog.Logger, or
+// slog.Default() if nil.
+func NewSlogLogger(l *slog.Logger, opts SlogOptions) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{logger: l, opts: opts}
+}
+
+// LogQuery logs the given query info, using the duration from
+// DBDurationFrom.
+func (l *SlogLogger) LogQuery(ctx context.Context, q string, args []any) {
+	l.LogQueryEvent(ctx, &QueryEvent{SQL: q, Args: args, Duration: DBDurationFrom(ctx)})
+}
+
+// LogQueryEvent logs the given QueryEvent.
+func (l *SlogLogger) LogQueryEvent(ctx context.Context, e *QueryEvent) {
+	level := l.opts.Level
+	msg := "query"
+	switch {
+	case e.Err != nil:
+		level = slog.LevelError
+		msg = "query failed"
+	case l.opts.SlowThreshold > 0 && e.Duration >= l.opts.SlowThreshold:
+		if level < slog.LevelWarn {
+			level = slog.LevelWarn
+		}
+		msg = "slow query"
+	case l.opts.SampleRate > 1:
+		if atomic.AddUint64(&l.count, 1)%l.opts.SampleRate != 1 {
+			return
+		}
+	}
+
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := make([]slog.Attr, 0, 9)
+	attrs = append(attrs,
+		slog.String("sql", e.SQL),
+		slog.Any("args", l.redact(e.SQL, e.Args)),
+		slog.Duration("duration", e.Duration),
+	)
+	if e.Table != "" {
+		attrs = append(attrs, slog.String("table", e.Table))
+	}
+	if e.Op != "" {
+		attrs = append(attrs, slog.String("op", string(e.Op)))
+	}
+	if e.RowsAffected > 0 {
+		attrs = append(attrs, slog.Int64("rows_affected", e.RowsAffected))
+	}
+	if e.RowsReturned > 0 {
+		attrs = append(attrs, slog.Int64("rows_returned", e.RowsReturned))
+	}
+	if e.InTx {
+		attrs = append(attrs, slog.Bool("in_tx", true))
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.Any("error", e.Err))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (l *SlogLogger) redact(q string, args []any) []any {
+	if l.opts.Redact == nil {
+		return args
+	}
+
+	redacted := make([]any, len(args))
+	for i, arg := range args {
+		redacted[i] = l.opts.Redact(q, i, arg)
+	}
+	return redacted
+}
@@ -0,0 +1,186 @@
+package schemable
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+)
+
+// StmtCacheStats are the counters of a DBClient's prepared statement cache.
+type StmtCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Size is the number of cached statements.
+	Size int
+}
+
+// stmtCache is a bounded LRU cache of prepared statements keyed by their SQL.
+// Each *sql.Stmt is prepared on a connection the first time it is used there,
+// so the cache works across the connection pool.
+type stmtCache struct {
+	db    *sql.DB
+	limit int
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	stats StmtCacheStats
+}
+
+// stmtEntry is a cached statement. It is closed once it has been evicted and
+// is no longer in use.
+type stmtEntry struct {
+	q       string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+func newStmtCache(db *sql.DB, limit int) *stmtCache {
+	return &stmtCache{
+		db:    db,
+		limit: limit,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get returns the prepared statement for the given SQL, preparing it if it is
+// not cached. Callers must call release once they are done with it.
+func (sc *stmtCache) get(ctx context.Context, q string) (*stmtEntry, error) {
+	sc.mu.Lock()
+	if el, ok := sc.items[q]; ok {
+		sc.lru.MoveToFront(el)
+		e := el.Value.(*stmtEntry)
+		e.refs++
+		sc.stats.Hits++
+		sc.mu.Unlock()
+		return e, nil
+	}
+	sc.stats.Misses++
+	sc.mu.Unlock()
+
+	stmt, err := sc.db.PrepareContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if el, ok := sc.items[q]; ok {
+		// prepared concurrently by another caller
+		stmt.Close()
+		sc.lru.MoveToFront(el)
+		e := el.Value.(*stmtEntry)
+		e.refs++
+		return e, nil
+	}
+
+	e := &stmtEntry{q: q, stmt: stmt, refs: 1}
+	sc.items[q] = sc.lru.PushFront(e)
+	for sc.lru.Len() > sc.limit {
+		sc.evict(sc.lru.Back())
+	}
+	return e, nil
+}
+
+// release marks the given statement as no longer in use by a caller.
+func (sc *stmtCache) release(e *stmtEntry) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	e.refs--
+	if e.evicted && e.refs == 0 {
+		e.stmt.Close()
+	}
+}
+
+// evict removes the given element, closing its statement if it is not in use.
+// The caller must hold the lock.
+func (sc *stmtCache) evict(el *list.Element) {
+	e := sc.lru.Remove(el).(*stmtEntry)
+	delete(sc.items, e.q)
+	e.evicted = true
+	sc.stats.Evictions++
+	if e.refs == 0 {
+		e.stmt.Close()
+	}
+}
+
+// clear evicts every cached statement.
+func (sc *stmtCache) clear() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	for sc.lru.Len() > 0 {
+		sc.evict(sc.lru.Back())
+	}
+}
+
+func (sc *stmtCache) snapshot() StmtCacheStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	stats := sc.stats
+	stats.Size = sc.lru.Len()
+	return stats
+}
+
+// handler returns the Handler at the end of a chain, which runs the Statements
+// built by Schemers and Recorders with cached prepared statements, and any
+// other SQL directly. Statements run in the given transaction if it is not
+// nil.
+func (sc *stmtCache) handler(tx *sql.Tx) Handler {
+	var direct Handler
+	if tx != nil {
+		direct = connHandler(tx)
+	} else {
+		direct = connHandler(sc.db)
+	}
+
+	return func(ctx context.Context, stmt Statement) Response {
+		q, ok := stmtCacheKey(ctx, stmt.SQL)
+		if !ok {
+			return direct(ctx, stmt)
+		}
+		e, err := sc.get(ctx, q)
+		if err != nil {
+			return Response{Err: err}
+		}
+		defer sc.release(e)
+
+		ps := e.stmt
+		if tx != nil {
+			// closed by the transaction when it ends
+			ps = tx.StmtContext(ctx, ps)
+		}
+
+		switch stmt.Kind {
+		case QueryStatement:
+			rows, err := ps.QueryContext(ctx, stmt.Args...)
+			return Response{Rows: rows, Err: err}
+		case QueryRowStatement:
+			row := ps.QueryRowContext(ctx, stmt.Args...)
+			return Response{Row: row, Err: row.Err()}
+		default:
+			res, err := ps.ExecContext(ctx, stmt.Args...)
+			return Response{Result: res, Err: err}
+		}
+	}
+}
+
+// stmtCacheKey returns the SQL of a statement built by a Schemer or Recorder,
+// without its sqlcommenter comment, since the comment's tags can change with
+// every request. It returns false for any other SQL, such as ad-hoc or
+// multi-statement SQL from Exec and Query, or SQL rewritten by a Middleware.
+func stmtCacheKey(ctx context.Context, q string) (string, bool) {
+	built, ok := ctx.Value(stmtKey).(string)
+	if !ok || !strings.HasPrefix(q, built) {
+		return "", false
+	}
+	comment := q[len(built):]
+	if comment == "" || (strings.HasPrefix(comment, " /*") && strings.Index(comment, "*/") == len(comment)-2) {
+		return built, true
+	}
+	return "", false
+}
@@ -0,0 +1,121 @@
+package schemable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Timeouts are default deadlines for queries whose context has no earlier
+// deadline. Zero values are disabled.
+type Timeouts struct {
+	// Read applies to Query and QueryRow. For reads by Schemers and
+	// Recorders, it also covers scanning the rows.
+	Read time.Duration
+	// Write applies to Exec.
+	Write time.Duration
+	// Txn applies to a whole transaction, from Begin to Commit or Rollback.
+	Txn time.Duration
+}
+
+// ErrTimeout matches any *TimeoutError with errors.Is.
+var ErrTimeout = errors.New("schemable: timeout")
+
+// TimeoutError is returned when a query exceeds one of the client's default
+// Timeouts, as opposed to a deadline or cancellation of the caller's context.
+type TimeoutError struct {
+	// Kind is "read", "write", or "transaction".
+	Kind    string
+	Timeout time.Duration
+	Err     error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("schemable: %s timeout of %s exceeded: %s", e.Kind, e.Timeout, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+// run sends the statement through the given handler, with the default read or
+// write timeout. The timeout of a read by a Schemer or Recorder lasts until it
+// is done with the rows. Other reads keep their timeout context until it
+// expires, since their rows outlive the call.
+func (s settings) run(ctx context.Context, h Handler, stmt Statement) Response {
+	kind, d := "read", s.timeouts.Read
+	if stmt.Kind == ExecStatement {
+		kind, d = "write", s.timeouts.Write
+	}
+
+	tctx, cancel, ok := withTimeout(ctx, d)
+	if !ok {
+		return h(ctx, stmt)
+	}
+
+	res := h(tctx, stmt)
+	if res.Err != nil && timedOut(ctx, tctx) {
+		res.Err = &TimeoutError{Kind: kind, Timeout: d, Err: res.Err}
+		res.Row = nil
+	}
+
+	if stmt.Kind == ExecStatement || res.Err != nil {
+		cancel()
+		return res
+	}
+	if rd, ok := ctx.Value(releaseKey).(*deadline); ok && rd.cancel == nil {
+		*rd = deadline{kind: kind, parent: ctx, ctx: tctx, cancel: cancel, timeout: d}
+	}
+	return res
+}
+
+// withTimeout returns a context with a deadline d from now, unless d is 0 or
+// the given context has an earlier deadline.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc, bool) {
+	if d <= 0 {
+		return ctx, nil, false
+	}
+	if dl, ok := ctx.Deadline(); ok && time.Until(dl) <= d {
+		return ctx, nil, false
+	}
+	tctx, cancel := context.WithTimeout(ctx, d)
+	return tctx, cancel, true
+}
+
+// timedOut returns true if the derived context from withTimeout exceeded its
+// deadline while its parent context is still active.
+func timedOut(parent, derived context.Context) bool {
+	return derived.Err() == context.DeadlineExceeded && parent.Err() == nil
+}
+
+// deadline is a default timeout that outlives a single call: the transaction
+// timeout of a TxnClient, or the read timeout of a query until it is done with
+// its rows.
+type deadline struct {
+	// kind is "read" or "transaction".
+	kind    string
+	parent  context.Context
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+// end releases the deadline's context, returning a TimeoutError if the given
+// error was caused by the timeout.
+func (t *deadline) end(err error) error {
+	if t == nil || t.cancel == nil {
+		return err
+	}
+
+	var te *TimeoutError
+	if err != nil && timedOut(t.parent, t.ctx) && !errors.As(err, &te) {
+		err = &TimeoutError{Kind: t.kind, Timeout: t.timeout, Err: err}
+	}
+	t.cancel()
+	return err
+}
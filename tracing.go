@@ -0,0 +1,54 @@
+package schemable
+
+import "context"
+
+// Tracer starts a span for every Schemer and Recorder operation. Set it on a
+// DBClient with SetTracer. See the schemableotel package for an OpenTelemetry
+// adapter.
+type Tracer interface {
+	// Start begins a span as a child of any span in the given context,
+	// returning a context with the new span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is an operation started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	// End completes the span, with an error status if err is not nil.
+	End(err error)
+}
+
+// Attribute is a key/value pair describing a Span.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span attribute keys, following OpenTelemetry semantic conventions where
+// they exist.
+const (
+	AttrTable        = "db.sql.table"
+	AttrOperation    = "db.operation"
+	AttrStatement    = "db.statement"
+	AttrRowsAffected = "db.rows_affected"
+	AttrRowsReturned = "db.rows_returned"
+)
+
+// startSpan starts a span named like "schemable.Insert comic_titles" with the
+// Tracer of the given client, if it has one.
+func startSpan(ctx context.Context, c Client, method string, op Op, table string) (context.Context, Span) {
+	tc, ok := c.(interface{ Tracer() Tracer })
+	if !ok {
+		return ctx, nil
+	}
+
+	t := tc.Tracer()
+	if t == nil {
+		return ctx, nil
+	}
+
+	return t.Start(ctx, "schemable."+method+" "+table,
+		Attribute{Key: AttrTable, Value: table},
+		Attribute{Key: AttrOperation, Value: string(op)},
+	)
+}